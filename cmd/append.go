@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var appendHeading string
+
+var appendCmd = &cobra.Command{
+	Use:   "append <path>",
+	Short: "Append stdin to a brainfile",
+	Long: `Append stdin to the end of the brainfile at path, or with --heading to the
+end of the section under the heading with that anchor, without opening an
+editor. For example:
+
+  echo "- $(date -I) deployed v1.2" | brain append ops/deploys.md --heading log`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		out, err := client.Append(args[0], appendHeading, data)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	appendCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	appendCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	appendCmd.Flags().StringVar(&appendHeading, "heading", "", "Anchor of the heading to append under e.g. deploy-log")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jedrw/brain/internal/brain"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var attachmentName string
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <path> <file>",
+	Short: "Attach a file to a brainfile",
+	Long: fmt.Sprintf(`Attach a file, such as an image, to the brainfile at path.
+
+Attachments are stored in a directory alongside the brainfile named after it
+with a %s suffix, link to them relative to the brainfile. They are moved and
+deleted along with it.`, brain.AssetsSuffix),
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if attachmentName == "" {
+			attachmentName = filepath.Base(args[1])
+		}
+
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		out, err := client.RunCommand(brain.ATTACH, f, args[0], attachmentName)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	attachCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	attachCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	attachCmd.Flags().StringVarP(&attachmentName, "name", "n", "", "Attachment name (default file name)")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jedrw/brain/internal/brain"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Query the audit log",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		var args []string
+		for _, name := range []string{"path", "user", "since", "until"} {
+			value, _ := cmd.Flags().GetString(name)
+			if value != "" {
+				args = append(args, "--"+name, value)
+			}
+		}
+
+		out, err := client.RunCommand(brain.AUDIT, nil, args...)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	auditCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	auditCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	auditCmd.Flags().String("path", "", "Only show entries for path (or paths under it)")
+	auditCmd.Flags().String("user", "", "Only show entries for key fingerprint")
+	auditCmd.Flags().String("since", "", "Only show entries after time (RFC3339, date or duration e.g. 7d)")
+	auditCmd.Flags().String("until", "", "Only show entries before time (RFC3339, date or duration e.g. 7d)")
+}
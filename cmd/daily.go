@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jedrw/brain/internal/brain"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/jedrw/brain/internal/editor"
+	"github.com/jedrw/brain/internal/periodic"
+	"github.com/spf13/cobra"
+)
+
+var (
+	periodicDate     string
+	periodicTemplate string
+)
+
+const periodicLong = `Open the %[1]s brainfile, creating it if it does not exist yet.
+
+Its path comes from the %[2]s config pattern, default %[3]q,
+where {{year}}, {{month}}, {{day}}, {{date}} and {{week}} are replaced. New
+brainfiles are created from the %[1]s template with {{previous}} set to a link
+to the most recent earlier entry.`
+
+var dailyCmd = &cobra.Command{
+	Use:   "daily",
+	Short: "Open today's brainfile",
+	Long:  fmt.Sprintf(periodicLong, periodic.Daily, "dailyPattern", config.DailyPatternDefault),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return openPeriodic(periodic.Daily, brainConfig.DailyPattern)
+	},
+}
+
+var weeklyCmd = &cobra.Command{
+	Use:   "weekly",
+	Short: "Open this week's brainfile",
+	Long:  fmt.Sprintf(periodicLong, periodic.Weekly, "weeklyPattern", config.WeeklyPatternDefault),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return openPeriodic(periodic.Weekly, brainConfig.WeeklyPattern)
+	},
+}
+
+// openPeriodic edits the brainfile for the period containing --date, or
+// creates it from a template linking to the previous one.
+func openPeriodic(period periodic.Period, pattern string) error {
+	date := time.Now()
+	if periodicDate != "" {
+		var err error
+		date, err = time.Parse(time.DateOnly, periodicDate)
+		if err != nil {
+			return fmt.Errorf("invalid date %q, use YYYY-MM-DD", periodicDate)
+		}
+	}
+
+	templateName := periodicTemplate
+	if templateName == "" {
+		templateName = string(period)
+	}
+
+	client, err := client.NewSSHClient(brainConfig)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	manifest, err := client.Manifest("")
+	if err != nil {
+		return err
+	}
+
+	filePath := periodic.Path(pattern, period, date)
+	paths := make([]string, 0, len(manifest))
+	titles := map[string]string{}
+	for _, entry := range manifest {
+		paths = append(paths, entry.Path)
+		titles[entry.Path] = entry.Title
+	}
+
+	var content []byte
+	if _, ok := titles[filePath]; ok {
+		out, err := client.RunCommand(brain.EDIT, nil, filePath)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(out, "ERROR") {
+			fmt.Println(out)
+			return nil
+		}
+
+		content = []byte(out)
+	} else {
+		var previous string
+		previousPath := periodic.Previous(pattern, period, periodic.Start(period, date), paths)
+		if previousPath != "" {
+			link, err := filepath.Rel(filepath.Dir(filePath), previousPath)
+			if err != nil {
+				return err
+			}
+
+			previous = fmt.Sprintf("Previous: [%s](%s)", titles[previousPath], filepath.ToSlash(link))
+		}
+
+		content, err = client.Template(templateName, filePath, periodic.Title(period, date), map[string]string{
+			"previous": previous,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	editedBytes, tempFilePath, err := editor.New(filePath, content)
+	if err != nil {
+		return err
+	}
+
+	return saveBrainfile(client, filePath, editedBytes, tempFilePath)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{dailyCmd, weeklyCmd} {
+		cmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+		cmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+		cmd.Flags().StringVar(&periodicDate, "date", "", "Open the brainfile for the period containing date (YYYY-MM-DD) instead of today")
+		cmd.Flags().StringVarP(&periodicTemplate, "template", "t", "", "Template for new brainfiles (default the command name)")
+	}
+}
@@ -1,9 +1,8 @@
 package cmd
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/jedrw/brain/internal/brain"
@@ -14,49 +13,75 @@ import (
 )
 
 var editCmd = &cobra.Command{
-	Use:   "edit [path]",
+	Use:   "edit [path[#heading]]",
 	Short: "Edit a brainfile",
-	Args:  cobra.ExactArgs(1),
+	Long: `Edit a brainfile, or the section under a heading, in $EDITOR.
+
+With --file or --stdin the brainfile or section is replaced by the content of
+a file or stdin. With --stdin or --no-edit it is saved without opening an
+editor, so edit can be used from scripts. --title and --tag set the
+brainfile's frontmatter.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filePath := args[0]
-		client, err := client.NewSSHClient(brainConfig)
+		_, anchor := brain.SplitAnchor(filePath)
+		if anchor != "" && (title != "" || len(tags) > 0) {
+			return errors.New("--title and --tag cannot be used when editing a section")
+		}
+
+		// Errors from here on are not usage errors
+		cmd.SilenceUsage = true
+		content, fromInput, err := readInput()
 		if err != nil {
 			return err
 		}
-		defer client.Close()
 
-		out, err := client.RunCommand(brain.EDIT, nil, filePath)
+		client, err := client.NewSSHClient(brainConfig)
 		if err != nil {
 			return err
 		}
+		defer client.Close()
 
-		if strings.HasPrefix(out, "ERROR") {
-			fmt.Println(out)
-			return nil
-		}
+		if !fromInput {
+			out, err := client.RunCommand(brain.EDIT, nil, filePath)
+			if err != nil {
+				return err
+			}
 
-		editedBytes, tempFilePath, err := editor.New(filePath, []byte(out))
-		if err != nil {
-			return err
+			if strings.HasPrefix(out, "ERROR") {
+				fmt.Println(out)
+				return nil
+			}
+
+			content = []byte(out)
 		}
 
-		out, err = client.RunCommand(brain.NEW, bytes.NewReader(editedBytes), filePath)
-		if err != nil {
-			return err
+		if title != "" || len(tags) > 0 {
+			content, err = brain.SetTitleAndTags(content, title, tags)
+			if err != nil {
+				return err
+			}
 		}
 
-		fmt.Print(out)
-		if strings.Contains(out, brain.ErrInvalidBrainNode.Error()) {
-			fmt.Printf("temp brainfile saved at: %s\n", tempFilePath)
-		} else {
-			os.Remove(tempFilePath)
+		editedBytes, tempFilePath := content, ""
+		if !fromStdin && !noEdit {
+			editedBytes, tempFilePath, err = editor.New(filePath, content)
+			if err != nil {
+				return err
+			}
 		}
 
-		return nil
+		return saveBrainfile(client, filePath, editedBytes, tempFilePath)
 	},
 }
 
 func init() {
 	editCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
 	editCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	editCmd.Flags().StringVar(&title, "title", "", "Set the title of the brainfile")
+	editCmd.Flags().StringSliceVar(&tags, "tag", nil, "Add a tag to the brainfile, can be repeated")
+	editCmd.Flags().StringVarP(&file, "file", "f", "", "Replace the brainfile with the content of a file")
+	editCmd.Flags().BoolVar(&fromStdin, "stdin", false, "Replace the brainfile with stdin without opening an editor")
+	editCmd.Flags().BoolVar(&noEdit, "no-edit", false, "Save without opening an editor")
+	editCmd.MarkFlagsMutuallyExclusive("file", "stdin")
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/jedrw/brain/internal/archive"
+	"github.com/jedrw/brain/internal/brain"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	exportFormat string
+	exportTitle  string
+	exportOutput string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "Export the brain as an archive or HTML document",
+	Long: `Export the brain, or the subtree at path, as an archive or HTML document.
+
+A tar.gz or zip archive contains every brainfile along with a manifest.json
+describing their titles, tags, hashes and modification times. Restore it with
+import-archive.
+
+The html format is a single self-contained document with a table of contents
+and links between brainfiles pointing within it, styled for printing to PDF.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportFormat != brain.ExportHTML {
+			_, err := archive.ParseFormat(exportFormat)
+			if err != nil {
+				return err
+			}
+		}
+
+		root := ""
+		if len(args) == 1 {
+			root = args[0]
+		}
+
+		var out io.Writer = os.Stdout
+		if exportFormat != brain.ExportHTML && exportOutput == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+			return errors.New("refusing to write archive to a terminal, use --output or redirect stdout")
+		}
+
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if exportOutput != "" {
+			f, err := os.Create(exportOutput)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			out = f
+		}
+
+		err = client.Export(exportFormat, exportTitle, root, out)
+		if err != nil && exportOutput != "" {
+			os.Remove(exportOutput)
+		}
+
+		return err
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	exportCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", string(archive.TarGz), "Format, tar.gz, zip or html")
+	exportCmd.Flags().StringVarP(&exportTitle, "title", "t", "", "HTML document title (default path)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default stdout)")
+}
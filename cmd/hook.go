@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/jedrw/brain/internal/brain"
+	"github.com/spf13/cobra"
+)
+
+var hookCmd = &cobra.Command{
+	Use:    "hook",
+	Short:  "Git hooks run by the brain server",
+	Hidden: true,
+	// Hooks run without a config file
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+var preReceiveCmd = &cobra.Command{
+	Use:           "pre-receive",
+	Short:         "Validate brainfiles in a push",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		schema, err := brain.SchemaFromEnv(os.Getenv(brain.SchemaEnv))
+		if err != nil {
+			return err
+		}
+
+		return brain.ValidatePush(os.Stdin, schema)
+	},
+}
+
+func init() {
+	hookCmd.AddCommand(preReceiveCmd)
+}
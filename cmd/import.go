@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/jedrw/brain/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var importOpts importer.Options
+
+var importCmd = &cobra.Command{
+	Use:   "import <dir> [path]",
+	Short: "Import a directory of markdown files",
+	Long: `Import every markdown file under dir into the brain, beneath path if given.
+
+Files without title frontmatter are given one from their first level one
+heading, or their file name if there isn't one. Files that are not valid
+brainfiles are reported and skipped. Hidden files and directories are ignored.
+
+With --obsidian notes are titled with their file name, inline #tags are added
+to their tags frontmatter and [[wikilinks]], including aliases, headings and
+![[embeds]], are rewritten as markdown links to the linked file. Files embedded
+in a note, such as images, are uploaded as attachments of it.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 2 {
+			importOpts.Prefix = args[1]
+		}
+
+		var dest importer.Uploader
+		if !importOpts.DryRun {
+			client, err := client.NewSSHClient(brainConfig)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			dest = client
+		}
+
+		result, err := importer.Import(args[0], dest, importOpts, os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		verb := "imported"
+		if importOpts.DryRun {
+			verb = "would import"
+		}
+
+		fmt.Printf("%s %d brainfile(s) and %d attachment(s), %d failed\n", verb, len(result.Imported), len(result.Attached), len(result.Failed))
+		if len(result.Failed) > 0 {
+			return fmt.Errorf("%d file(s) failed to import", len(result.Failed))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	importCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	importCmd.Flags().BoolVarP(&importOpts.DryRun, "dry-run", "n", false, "Validate and report without uploading")
+	importCmd.Flags().BoolVar(&importOpts.Obsidian, "obsidian", false, "Convert from an Obsidian vault")
+}
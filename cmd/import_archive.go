@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedrw/brain/internal/archive"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var importArchiveFormat string
+
+var importArchiveCmd = &cobra.Command{
+	Use:   "import-archive <file>",
+	Short: "Restore an archive created by export",
+	Long: `Restore an archive created by export, saving every brainfile in it with its
+original modification time. Nothing is saved if any brainfile is invalid,
+and if any fail to save the files that were restored and those that failed
+are listed. The format is taken from the file extension unless --format is
+set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var format archive.Format
+		var err error
+		if importArchiveFormat != "" {
+			format, err = archive.ParseFormat(importArchiveFormat)
+		} else {
+			format, err = archive.FormatFromPath(args[0])
+		}
+
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		out, err := client.ImportArchive(format, f)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	importArchiveCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	importArchiveCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	importArchiveCmd.Flags().StringVarP(&importArchiveFormat, "format", "f", "", "Archive format, tar.gz or zip")
+}
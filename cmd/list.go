@@ -9,6 +9,12 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	listSort  string
+	listSince string
+	listLong  bool
+)
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List brainfiles",
@@ -20,7 +26,20 @@ var listCmd = &cobra.Command{
 		}
 		defer client.Close()
 
-		out, err := client.RunCommand(brain.LIST, nil)
+		var args []string
+		if listSort != "" {
+			args = append(args, "--sort", listSort)
+		}
+
+		if listSince != "" {
+			args = append(args, "--since", listSince)
+		}
+
+		if listLong {
+			args = append(args, "--long")
+		}
+
+		out, err := client.RunCommand(brain.LIST, nil, args...)
 		if err != nil {
 			return err
 		}
@@ -33,4 +52,7 @@ var listCmd = &cobra.Command{
 func init() {
 	listCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
 	listCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "Sort by path or title, or by created or updated newest first")
+	listCmd.Flags().StringVar(&listSince, "since", "", "Only list brainfiles updated after time (RFC3339, date or duration e.g. 7d)")
+	listCmd.Flags().BoolVarP(&listLong, "long", "l", false, "Show when and by whom each brainfile was last updated")
 }
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/jedrw/brain/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+var lspDir string
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server for brainfiles",
+	Long: fmt.Sprintf(`Run a Language Server Protocol server for brainfiles over stdio, for use
+from editors such as Neovim and VS Code.
+
+Brainfiles are edited in a local directory whose paths match the brain, such
+as a mirror kept by brain sync, by default the editor's workspace. The server
+completes links to brainfiles after [[ or ]( and tags in frontmatter, goes to
+the definition of links, fetching brainfiles missing from the directory, and
+reports invalid brainfiles and links to brainfiles that do not exist.
+
+The %s command saves the document given as its argument to the brain and
+%s reloads the brainfiles the server knows about.`, lsp.CommandSave, lsp.CommandRefresh),
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		return lsp.NewServer(client, lspDir).Serve(os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	lspCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	lspCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	lspCmd.Flags().StringVarP(&lspDir, "dir", "d", "", "Brainfile directory (default the editor's workspace)")
+}
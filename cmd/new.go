@@ -3,7 +3,9 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -14,15 +16,31 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var file string
+var (
+	file         string
+	templateName string
+	title        string
+	tags         []string
+	fromStdin    bool
+	noEdit       bool
+)
 
 var newCmd = &cobra.Command{
 	Use:   "new [path]",
 	Short: "New brainfile",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Create a brainfile, editing it in $EDITOR before it is saved.
+
+With --stdin or --no-edit the brainfile is saved without opening an editor, so
+new can be used from scripts. --title and --tag set its frontmatter, adding it
+if the content has none.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var filePath string
 		if len(args) == 0 {
+			if fromStdin || noEdit {
+				return errors.New("path is required with --stdin or --no-edit")
+			}
+
 			reader := bufio.NewReader(os.Stdin)
 			fmt.Print("Enter name: ")
 			name, _ := reader.ReadString('\n')
@@ -31,26 +49,37 @@ var newCmd = &cobra.Command{
 			filePath = args[0]
 		}
 
-		inputFile, err := cmd.Flags().GetString("file")
+		initialContent, fromInput, err := readInput()
 		if err != nil {
 			return err
 		}
 
-		var initialContent []byte
-		if inputFile != "" {
-			initialContent, err = os.ReadFile(inputFile)
+		if !fromInput && templateName != "" {
+			initialContent, err = fetchTemplate(templateName, strings.TrimSpace(filePath), title, nil)
 			if err != nil {
 				return err
 			}
-		} else {
+		} else if !fromInput {
 			initialContent = brain.BrainfileTemplate
 		}
 
-		newBytes, tempFilePath, err := editor.New(filePath, initialContent)
-		if err != nil {
-			return err
+		if title != "" || len(tags) > 0 {
+			initialContent, err = brain.SetTitleAndTags(initialContent, title, tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		newBytes, tempFilePath := initialContent, ""
+		if !fromStdin && !noEdit {
+			newBytes, tempFilePath, err = editor.New(filePath, initialContent)
+			if err != nil {
+				return err
+			}
 		}
 
+		// Errors from here on are not usage errors
+		cmd.SilenceUsage = true
 		config, err := config.New(configPath, cmd.Flags())
 		if err != nil {
 			return err
@@ -62,24 +91,64 @@ var newCmd = &cobra.Command{
 		}
 		defer client.Close()
 
-		out, err := client.RunCommand(brain.NEW, bytes.NewReader(newBytes), filePath)
-		if err != nil {
-			return err
-		}
+		return saveBrainfile(client, filePath, newBytes, tempFilePath)
+	},
+}
 
-		fmt.Print(out)
-		if strings.Contains(out, brain.ErrInvalidBrainNode.Error()) {
-			fmt.Printf("temp brainfile saved at: %s\n", tempFilePath)
-		} else {
-			os.Remove(tempFilePath)
+// readInput reads the content given with --file or --stdin, reporting
+// whether either was.
+func readInput() ([]byte, bool, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		return data, true, err
+	}
+
+	if fromStdin {
+		data, err := io.ReadAll(os.Stdin)
+		return data, true, err
+	}
+
+	return nil, false, nil
+}
+
+type commandRunner interface {
+	RunCommand(command string, in io.Reader, args ...string) (string, error)
+}
+
+// saveBrainfile saves data edited in tempFilePath, if any, removing it unless
+// the brainfile was invalid.
+func saveBrainfile(client commandRunner, filePath string, data []byte, tempFilePath string) error {
+	out, err := client.RunCommand(brain.NEW, bytes.NewReader(data), filePath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+	if tempFilePath == "" {
+		if strings.HasPrefix(out, "ERROR") {
+			return errors.New("brainfile was not saved")
 		}
 
 		return nil
-	},
+	}
+
+	if strings.Contains(out, brain.ErrInvalidBrainNode.Error()) {
+		fmt.Printf("temp brainfile saved at: %s\n", tempFilePath)
+	} else {
+		os.Remove(tempFilePath)
+	}
+
+	return nil
 }
 
 func init() {
 	newCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
 	newCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
-	newCmd.Flags().StringVarP(&file, "file", "f", "", "File")
+	newCmd.Flags().StringVarP(&file, "file", "f", "", "Start from the content of a file")
+	newCmd.Flags().StringVarP(&templateName, "template", "t", "", "Start from a server template, see templates")
+	newCmd.Flags().StringVar(&title, "title", "", "Title of the brainfile (default from path for templates)")
+	newCmd.Flags().StringSliceVar(&tags, "tag", nil, "Tag the brainfile, can be repeated")
+	newCmd.Flags().BoolVar(&fromStdin, "stdin", false, "Read the brainfile from stdin and save it without opening an editor")
+	newCmd.Flags().BoolVar(&noEdit, "no-edit", false, "Save without opening an editor")
+	newCmd.MarkFlagsMutuallyExclusive("file", "template", "stdin")
 }
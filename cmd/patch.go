@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var patchCmd = &cobra.Command{
+	Use:   "patch <path> [diff]",
+	Short: "Apply a unified diff to a brainfile",
+	Long: `Apply a unified diff, read from the diff file or stdin, to the brainfile at
+path. The patch fails without changing anything if a hunk does not apply.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var diff []byte
+		var err error
+		if len(args) == 2 {
+			diff, err = os.ReadFile(args[1])
+		} else {
+			diff, err = io.ReadAll(os.Stdin)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		out, err := client.Patch(args[0], diff)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	patchCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	patchCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+}
@@ -12,10 +12,16 @@ var (
 	configPath     string
 	address        string
 	port           int
+	adminPort      int
 	contentDir     string
 	hostKeyPath    string
+	auditLogPath   string
+	gitDir         string
+	templatesDir   string
 	authorizedKeys string
 	keyPath        string
+	brainName      string
+	profile        string
 )
 
 var rootCmd = &cobra.Command{
@@ -44,11 +50,29 @@ func Execute() error {
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config-path", "c", "", "Path to config file")
 	rootCmd.PersistentFlags().IntVarP(&port, config.PortFlag, "p", config.PortDefault, "Port to use to listen/connect")
+	rootCmd.PersistentFlags().StringVar(&profile, config.ProfileFlag, "", "Client profile to use, the config's default profile if unset")
+	rootCmd.PersistentFlags().StringVarP(&brainName, config.BrainFlag, "b", "", "Named brain to use, also set by addressing the host as host:/name")
 	rootCmd.AddCommand(serverCmd)
 	rootCmd.AddCommand(newCmd)
+	rootCmd.AddCommand(templatesCmd)
+	rootCmd.AddCommand(dailyCmd)
+	rootCmd.AddCommand(weeklyCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(tasksCmd)
+	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(moveCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(appendCmd)
+	rootCmd.AddCommand(patchCmd)
+	rootCmd.AddCommand(attachCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(lspCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importArchiveCmd)
+	rootCmd.AddCommand(hookCmd)
 	cobra.EnableCommandSorting = false
 }
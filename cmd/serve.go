@@ -15,15 +15,17 @@ var serverCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Brainfiles server",
 	Args:  cobra.NoArgs,
-	RunE: func(cmd *cobra.Command, _ []string) error {
-		config, err := config.New(configPath, cmd.Flags())
-		if err != nil {
-			return err
-		}
+	// Profiles only apply to clients
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		brainConfig, err = config.NewServer(configPath, cmd.Flags())
 
+		return err
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
 		ctx, cancel := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		brain, err := brain.NewBrain(ctx, config)
+		brain, err := brain.NewBrain(ctx, brainConfig)
 		if err != nil {
 			return err
 		}
@@ -44,5 +46,9 @@ var serverCmd = &cobra.Command{
 func init() {
 	serverCmd.Flags().StringVarP(&contentDir, config.ContentDirFlag, "d", config.ContentDirDefault, "Path to content dir")
 	serverCmd.Flags().StringVarP(&hostKeyPath, config.HostKeyPathFlag, "k", config.HostKeyPathDefault, "Path to host key")
+	serverCmd.Flags().IntVar(&adminPort, config.AdminPortFlag, config.AdminPortDefault, "Port to serve health and metrics endpoints on, disabled if unset")
+	serverCmd.Flags().StringVar(&auditLogPath, config.AuditLogPathFlag, config.AuditLogPathDefault, "Path to audit log")
+	serverCmd.Flags().StringVar(&gitDir, config.GitDirFlag, "", "Path to git repository mirroring the content dir (git disabled if unset)")
+	serverCmd.Flags().StringVar(&templatesDir, config.TemplatesDirFlag, "", "Path to dir of brainfile templates")
 	serverCmd.Flags().StringVarP(&authorizedKeys, config.AuthorizedKeysFlag, "z", "", "Authorized keys (comma separated)")
 }
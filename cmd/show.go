@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/anmitsu/go-shlex"
+	"github.com/jedrw/brain/internal/brain"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/jedrw/brain/internal/render"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	defaultPager = "less -R"
+	defaultWidth = 80
+)
+
+// page writes out through $PAGER when stdout is a terminal.
+func page(out string, noPager bool) error {
+	if noPager || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print(out)
+		return nil
+	}
+
+	pager, isSet := os.LookupEnv("PAGER")
+	if !isSet || pager == "" {
+		pager = defaultPager
+	}
+
+	pagerArgs, err := shlex.Split(pager, true)
+	if err != nil || len(pagerArgs) == 0 {
+		fmt.Print(out)
+		return nil
+	}
+
+	pagerCmd := exec.Command(pagerArgs[0], pagerArgs[1:]...)
+	pagerCmd.Stdin = strings.NewReader(out)
+	pagerCmd.Stdout = os.Stdout
+	pagerCmd.Stderr = os.Stderr
+	err = pagerCmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		// The pager could not be started
+		fmt.Print(out)
+	}
+
+	return nil
+}
+
+func terminalWidth() int {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 {
+		return defaultWidth
+	}
+
+	return width
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show [path[#heading]]",
+	Short: "Show a brainfile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		raw, _ := cmd.Flags().GetBool("raw")
+		html, _ := cmd.Flags().GetBool("html")
+		noPager, _ := cmd.Flags().GetBool("no-pager")
+		if raw && html {
+			return fmt.Errorf("--raw and --html are mutually exclusive")
+		}
+
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		out, err := showBrainfile(client, args[0], raw, html, terminalWidth())
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(out, "ERROR") {
+			fmt.Print(out)
+			return nil
+		}
+
+		return page(out, noPager)
+	},
+}
+
+// showBrainfile returns the brainfile at filePath as it is stored with raw,
+// rendered as HTML by the server with html, or otherwise rendered for a
+// terminal width wide. Errors from the server are returned as they are.
+func showBrainfile(client commandRunner, filePath string, raw, html bool, width int) (string, error) {
+	commandArgs := []string{filePath}
+	if html {
+		commandArgs = append([]string{"--html"}, commandArgs...)
+	}
+
+	out, err := client.RunCommand(brain.SHOW, nil, commandArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	if raw || html || strings.HasPrefix(out, "ERROR") {
+		return out, nil
+	}
+
+	_, body, err := brain.SplitFrontmatter([]byte(out))
+	if err != nil {
+		return "", err
+	}
+
+	return render.Markdown(body, width, render.StyleAuto)
+}
+
+func init() {
+	showCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	showCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	showCmd.Flags().Bool("raw", false, "Print the raw brainfile")
+	showCmd.Flags().Bool("html", false, "Print the brainfile rendered as HTML")
+	showCmd.Flags().Bool("no-pager", false, "Do not page output")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jedrw/brain/internal/brain"
+)
+
+// fakeRunner returns out for any command, recording the last it was given.
+type fakeRunner struct {
+	out     string
+	command []string
+}
+
+func (f *fakeRunner) RunCommand(command string, in io.Reader, args ...string) (string, error) {
+	f.command = append([]string{command}, args...)
+	return f.out, nil
+}
+
+func TestShowBrainfile(t *testing.T) {
+	brainfile := "---\ntitle: Runbook\ntags: [ops]\n---\n# Failover\n\nPromote the **replica**.\n"
+	tests := []struct {
+		name     string
+		raw      bool
+		html     bool
+		out      string
+		command  []string
+		expected string
+	}{
+		{"raw", true, false, brainfile, []string{brain.SHOW, "ops/runbook.md"}, brainfile},
+		{"html", false, true, "<h1>Failover</h1>\n", []string{brain.SHOW, "--html", "ops/runbook.md"}, "<h1>Failover</h1>\n"},
+		{"error", false, false, "ERROR: brainfile does not exist\n", []string{brain.SHOW, "ops/runbook.md"}, "ERROR: brainfile does not exist\n"},
+	}
+
+	for _, test := range tests {
+		runner := &fakeRunner{out: test.out}
+		out, err := showBrainfile(runner, "ops/runbook.md", test.raw, test.html, 80)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if !slices.Equal(runner.command, test.command) {
+			t.Errorf("%s: expected command %v got %v", test.name, test.command, runner.command)
+		}
+
+		if out != test.expected {
+			t.Errorf("%s: expected %q got %q", test.name, test.expected, out)
+		}
+	}
+
+	// Rendered for the terminal without its frontmatter
+	runner := &fakeRunner{out: brainfile}
+	out, err := showBrainfile(runner, "ops/runbook.md", false, false, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(runner.command, []string{brain.SHOW, "ops/runbook.md"}) {
+		t.Errorf("expected command %v got %v", []string{brain.SHOW, "ops/runbook.md"}, runner.command)
+	}
+
+	_, body, _ := brain.SplitFrontmatter([]byte(brainfile))
+	if out == string(body) || !strings.Contains(out, "Promote the") || strings.Contains(out, "title:") {
+		t.Errorf("expected the body to be rendered got %q", out)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/jedrw/brain/internal/mirror"
+	"github.com/spf13/cobra"
+)
+
+var mirrorDir string
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync a local mirror of the brain",
+	Long: fmt.Sprintf(`Sync a local mirror of the brain for offline reading and editing.
+
+Changes made on either side since the last sync, to brainfiles and their
+attachments, are applied to the other. Files changed on both sides keep the
+local version and the remote version is written alongside it with a %s
+suffix. Resolve the conflict by editing the local file, removing the %s
+file and syncing again.`, mirror.ConflictSuffix, mirror.ConflictSuffix),
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if mirrorDir == "" {
+			name := brainConfig.Address
+			if brainConfig.Brain != "" {
+				// Kept apart from the default brain's mirror, which would
+				// otherwise sync it
+				name = brainConfig.Brain + "@" + name
+			}
+
+			mirrorDir = filepath.Join(xdg.DataHome, "brain", "mirror", name)
+		}
+
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		result, err := mirror.Sync(mirrorDir, client, os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("synced %s: %d pulled, %d pushed, %d deleted, %d conflicts, %d errors\n",
+			mirrorDir, len(result.Pulled), len(result.Pushed), len(result.Deleted), len(result.Conflicts), len(result.Errors))
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("%d brainfile(s) failed to sync", len(result.Errors))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	syncCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	syncCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	syncCmd.Flags().StringVarP(&mirrorDir, "dir", "d", "", "Mirror directory (default $XDG_DATA_HOME/brain/mirror/[<brain>@]<address>)")
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jedrw/brain/internal/brain"
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tasksOwner string
+	tasksDue   string
+	tasksPath  string
+	tasksAll   bool
+)
+
+var tasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "List open tasks",
+	Long: `List open task list items ("- [ ]") across the brain.
+
+Tasks can be annotated with @due(YYYY-MM-DD) and an @owner. Each is listed by
+its id, the path and line of the brainfile it is in and a hash of its text,
+which is passed to tasks done to tick it off. Ticking off a task whose text
+has changed since it was listed fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		var args []string
+		if tasksOwner != "" {
+			args = append(args, "--owner", tasksOwner)
+		}
+
+		if tasksDue != "" {
+			args = append(args, "--due", tasksDue)
+		}
+
+		if tasksPath != "" {
+			args = append(args, "--path", tasksPath)
+		}
+
+		if tasksAll {
+			args = append(args, "--all")
+		}
+
+		out, err := client.RunCommand(brain.TASKS, nil, args...)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+var tasksDoneCmd = &cobra.Command{
+	Use:   "done [id]",
+	Short: "Tick off a task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		out, err := client.RunCommand(brain.TASK_DONE, nil, args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	tasksCmd.PersistentFlags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	tasksCmd.PersistentFlags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+	tasksCmd.Flags().StringVar(&tasksOwner, "owner", "", "Only list tasks for owner")
+	tasksCmd.Flags().StringVar(&tasksDue, "due", "", "Only list tasks due by date (YYYY-MM-DD) or within a duration e.g. 7d")
+	tasksCmd.Flags().StringVar(&tasksPath, "path", "", "Only list tasks in brainfiles under path")
+	tasksCmd.Flags().BoolVar(&tasksAll, "all", false, "Include completed tasks")
+	tasksCmd.AddCommand(tasksDoneCmd)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jedrw/brain/internal/client"
+	"github.com/jedrw/brain/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List brainfile templates",
+	Long: `List the brainfile templates available to new --template.
+
+Templates are markdown files in the server's templates dir, named after the
+file without its extension. The variables {{date}}, {{time}}, {{user}} and
+{{title}} are replaced when a brainfile is created from one. The built in
+daily and weekly templates used by daily and weekly can be overridden in the
+same way.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		client, err := client.NewSSHClient(brainConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		names, err := client.Templates()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(strings.Join(names, "\n"))
+		return nil
+	},
+}
+
+// fetchTemplate renders the named template for a new brainfile at path.
+func fetchTemplate(name, path, title string, vars map[string]string) ([]byte, error) {
+	client, err := client.NewSSHClient(brainConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.Template(name, path, title, vars)
+}
+
+func init() {
+	templatesCmd.Flags().StringVarP(&address, config.AddressFlag, "a", config.AddressDefault, "Brain host address")
+	templatesCmd.Flags().StringVarP(&keyPath, config.KeyPathFlag, "i", config.KeyPathDefault, "Key path")
+}
@@ -67,6 +67,11 @@ export = async () => {
             server: "tshare.lupinelab",
             path: contentDir,
           },
+          audit: {
+            nfs: {
+              path: `${contentDir}-audit`,
+            },
+          },
         },
         ingress: {
           http: {
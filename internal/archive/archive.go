@@ -0,0 +1,274 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	TarGz Format = "tar.gz"
+	Zip   Format = "zip"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown archive format, use tar.gz or zip")
+	ErrTooLarge      = errors.New("archive exceeds size limit")
+)
+
+type File struct {
+	Path    string
+	Data    []byte
+	ModTime time.Time
+}
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(format) {
+	case TarGz, Zip:
+		return Format(format), nil
+	case "tgz":
+		return TarGz, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// FormatFromPath returns the format implied by the extension of filePath.
+func FormatFromPath(filePath string) (Format, error) {
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(filePath, ext) {
+			return ParseFormat(strings.TrimPrefix(ext, "."))
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, filePath)
+}
+
+// Writer streams files to an archive as they are added.
+type Writer struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+	zw *zip.Writer
+}
+
+func NewWriter(w io.Writer, format Format) (*Writer, error) {
+	switch format {
+	case TarGz:
+		gw := gzip.NewWriter(w)
+		return &Writer{gw: gw, tw: tar.NewWriter(gw)}, nil
+	case Zip:
+		return &Writer{zw: zip.NewWriter(w)}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// Add copies size bytes from r to the archive as the file at filePath.
+func (w *Writer) Add(filePath string, modTime time.Time, size int64, r io.Reader) error {
+	var fw io.Writer
+	var err error
+	if w.tw != nil {
+		err = w.tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     filePath,
+			Size:     size,
+			Mode:     0644,
+			ModTime:  modTime,
+		})
+		fw = w.tw
+	} else {
+		fw, err = w.zw.CreateHeader(&zip.FileHeader{
+			Name:     filePath,
+			Method:   zip.Deflate,
+			Modified: modTime,
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	n, err := io.CopyN(fw, r, size)
+	if err == io.EOF {
+		return fmt.Errorf("%s: expected %d bytes got %d", filePath, size, n)
+	}
+
+	return err
+}
+
+// Close finishes the archive without closing the underlying writer.
+func (w *Writer) Close() error {
+	if w.zw != nil {
+		return w.zw.Close()
+	}
+
+	err := w.tw.Close()
+	if err != nil {
+		return err
+	}
+
+	return w.gw.Close()
+}
+
+// Write writes files to w as an archive.
+func Write(w io.Writer, format Format, files []File) error {
+	aw, err := NewWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		err = aw.Add(file.Path, file.ModTime, int64(len(file.Data)), bytes.NewReader(file.Data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return aw.Close()
+}
+
+// Limits bounds the size of an archive being read as it is held in memory.
+type Limits struct {
+	// Archive is the size of the archive and of the files in it combined
+	Archive int64
+	// File is the size of each file in the archive
+	File int64
+}
+
+// readAll reads r up to max bytes, failing if there is more.
+func readAll(r io.Reader, max int64, name string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrTooLarge, name, max)
+	}
+
+	return data, nil
+}
+
+// cappedReader fails once more than remaining bytes are read, recording the
+// error as decompressors wrap it.
+type cappedReader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+	err       error
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		// Only an error if there is more to read
+		n, err := c.r.Read(make([]byte, 1))
+		if n > 0 {
+			c.err = fmt.Errorf("%w: archive is larger than %d bytes", ErrTooLarge, c.max)
+			return 0, c.err
+		}
+
+		return 0, err
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	return n, err
+}
+
+// Read returns the regular files in an archive, failing if it or any file in
+// it exceeds limits. Zip archives must be read whole as their index is at the
+// end.
+func Read(r io.Reader, format Format, limits Limits) ([]File, error) {
+	var files []File
+	// Extracted files count towards the archive limit so small archives
+	// of highly compressed files can't exceed it
+	remaining := limits.Archive
+	readFile := func(r io.Reader, name string) ([]byte, error) {
+		data, err := readAll(r, min(limits.File, remaining), name)
+		if errors.Is(err, ErrTooLarge) && remaining < limits.File {
+			return nil, fmt.Errorf("%w: files in archive are larger than %d bytes combined", ErrTooLarge, limits.Archive)
+		}
+
+		remaining -= int64(len(data))
+		return data, err
+	}
+
+	switch format {
+	case TarGz:
+		capped := &cappedReader{r: r, remaining: limits.Archive, max: limits.Archive}
+		gr, err := gzip.NewReader(capped)
+		if err != nil {
+			return nil, cmp.Or(capped.err, err)
+		}
+
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return files, nil
+			}
+
+			if err != nil {
+				return nil, cmp.Or(capped.err, err)
+			}
+
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+
+			data, err := readFile(tr, header.Name)
+			if err != nil {
+				return nil, cmp.Or(capped.err, err)
+			}
+
+			files = append(files, File{Path: path.Clean(header.Name), Data: data, ModTime: header.ModTime})
+		}
+	case Zip:
+		data, err := readAll(r, limits.Archive, "archive")
+		if err != nil {
+			return nil, err
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+
+			data, err := readFile(rc, f.Name)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, File{Path: path.Clean(f.Name), Data: data, ModTime: f.Modified})
+		}
+
+		return files, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
@@ -0,0 +1,96 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	modTime := time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC)
+	files := []File{
+		{Path: "manifest.json", Data: []byte("[]"), ModTime: modTime},
+		{Path: "nested/note.md", Data: []byte("---\ntitle: Note\n---\n"), ModTime: modTime},
+	}
+
+	for _, format := range []Format{TarGz, Zip} {
+		var buf bytes.Buffer
+		err := Write(&buf, format, files)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+
+		read, err := Read(&buf, format, Limits{Archive: 1 << 20, File: 1 << 20})
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+
+		if len(read) != len(files) {
+			t.Fatalf("%s: expected %d files got %d", format, len(files), len(read))
+		}
+
+		for i, file := range read {
+			if file.Path != files[i].Path || !bytes.Equal(file.Data, files[i].Data) || !file.ModTime.Equal(modTime) {
+				t.Errorf("%s: expected %+v got %+v", format, files[i], file)
+			}
+		}
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	modTime := time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		files  []File
+		limits Limits
+	}{
+		{"large file", []File{{Path: "large.md", Data: make([]byte, 2048)}}, Limits{Archive: 1 << 20, File: 1024}},
+		// Compresses to far less than the archive limit
+		{"zip bomb", []File{
+			{Path: "a.md", Data: make([]byte, 1024)},
+			{Path: "b.md", Data: make([]byte, 1024)},
+		}, Limits{Archive: 1536, File: 1024}},
+		{"large archive", []File{{Path: "random.md", Data: []byte(strings.Repeat("0123456789abcdef", 512))}}, Limits{Archive: 64, File: 1 << 20}},
+	}
+
+	for _, test := range tests {
+		for _, format := range []Format{TarGz, Zip} {
+			for i := range test.files {
+				test.files[i].ModTime = modTime
+			}
+
+			var buf bytes.Buffer
+			err := Write(&buf, format, test.files)
+			if err != nil {
+				t.Fatalf("%s %s: %s", test.name, format, err)
+			}
+
+			_, err = Read(&buf, format, test.limits)
+			if !errors.Is(err, ErrTooLarge) {
+				t.Errorf("%s %s: expected %v got %v", test.name, format, ErrTooLarge, err)
+			}
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]Format{
+		"backup.tar.gz": TarGz,
+		"backup.tgz":    TarGz,
+		"backup.zip":    Zip,
+	}
+
+	for filePath, expected := range tests {
+		got, err := FormatFromPath(filePath)
+		if err != nil || got != expected {
+			t.Errorf("%s: expected %s got %s (%v)", filePath, expected, got, err)
+		}
+	}
+
+	_, err := FormatFromPath("backup.rar")
+	if err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,95 @@
+package brain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/charmbracelet/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// serverStatus returns the status of the default brain including that of
+// every named brain.
+func (b *Brain) serverStatus() Status {
+	status := b.status.get()
+	for name, nb := range b.brains {
+		if status.Brains == nil {
+			status.Brains = map[string]Status{}
+		}
+
+		status.Brains[name] = nb.status.get()
+	}
+
+	return status
+}
+
+// healthz reports unhealthy while the tree of any brain is failing to load.
+func (b *Brain) healthz(w http.ResponseWriter, _ *http.Request) {
+	status := b.serverStatus()
+	w.Header().Set("Content-Type", "application/json")
+	healthy := status.Error == ""
+	for _, brainStatus := range status.Brains {
+		healthy = healthy && brainStatus.Error == ""
+	}
+
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	err := json.NewEncoder(w).Encode(status)
+	if err != nil {
+		log.Warn("failed to write health response", "err", err)
+	}
+}
+
+// readyz reports ready once the trees of every brain have loaded. Failing
+// update tasks only degrade the status as the brain can still be served.
+func (b *Brain) readyz(w http.ResponseWriter, _ *http.Request) {
+	status := b.serverStatus()
+	w.Header().Set("Content-Type", "application/json")
+	isReady := status.Loaded
+	for _, brainStatus := range status.Brains {
+		isReady = isReady && brainStatus.Loaded
+	}
+
+	if !isReady {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	err := json.NewEncoder(w).Encode(status)
+	if err != nil {
+		log.Warn("failed to write ready response", "err", err)
+	}
+}
+
+func (b *Brain) serveAdmin() error {
+	if b.config.AdminPort <= 0 {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", b.config.AdminPort))
+	if err != nil {
+		return err
+	}
+
+	log.Info(fmt.Sprintf("opened admin listener on port: %d", b.config.AdminPort))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", b.healthz)
+	mux.HandleFunc("GET /readyz", b.readyz)
+	mux.Handle("GET /metrics", promhttp.Handler())
+	b.adminServer = &http.Server{Handler: mux}
+
+	go func() {
+		log.Info("starting admin server")
+		if err := b.adminServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Info("error from admin server")
+			log.Fatal(err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package brain
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func checkReadyz(t *testing.T, b *Brain, expected int) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	b.readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != expected {
+		t.Errorf("expected readyz %d got %d: %s", expected, rec.Code, rec.Body)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	b := &Brain{status: &status{}}
+	checkReadyz(t, b, http.StatusServiceUnavailable)
+
+	b = newTestBrain(t, map[string]string{"a.md": "---\ntitle: A\n---\n"})
+	checkReadyz(t, b, http.StatusOK)
+
+	// A failing update task such as the docs build does not stop the brain
+	// being served
+	b.status.tasksRan(map[string]string{"mkdocs build": "exit status 1"})
+	checkReadyz(t, b, http.StatusOK)
+
+	b.brains = map[string]*Brain{"team": {status: &status{}}}
+	checkReadyz(t, b, http.StatusServiceUnavailable)
+}
+
+func testKey(t *testing.T) (ssh.PublicKey, string) {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, string(gossh.MarshalAuthorizedKey(key))
+}
+
+func TestMetrics(t *testing.T) {
+	b := newTestBrain(t, map[string]string{
+		"a.md":        "---\ntitle: A\n---\n",
+		"nested/b.md": "---\ntitle: B\n---\n",
+		"invalid.md":  "---\ntitle: [\n---\n",
+	})
+
+	if nodes := testutil.ToFloat64(treeNodes.WithLabelValues(defaultBrainLabel)); nodes != 2 {
+		t.Errorf("expected 2 nodes got %v", nodes)
+	}
+
+	if failures := testutil.ToFloat64(treeParseFailures.WithLabelValues(defaultBrainLabel)); failures != 1 {
+		t.Errorf("expected 1 parse failure got %v", failures)
+	}
+
+	key, authorizedKey := testKey(t)
+	b.config.AuthorizedKeys = []string{authorizedKey}
+	handler := b.sshHandler(func(ssh.Session) {})
+	ok := commandsTotal.WithLabelValues(APPEND, "ok")
+	failed := commandsTotal.WithLabelValues(APPEND, "error")
+	okBefore, failedBefore := testutil.ToFloat64(ok), testutil.ToFloat64(failed)
+
+	for _, path := range []string{"a.md", "missing.md"} {
+		s := newTestSession("more\n", APPEND, path)
+		s.key = key
+		handler(s)
+	}
+
+	if testutil.ToFloat64(ok)-okBefore != 1 || testutil.ToFloat64(failed)-failedBefore != 1 {
+		t.Errorf("expected 1 ok and 1 failed append got %v and %v", testutil.ToFloat64(ok)-okBefore, testutil.ToFloat64(failed)-failedBefore)
+	}
+
+	if active := testutil.ToFloat64(sessionsActive); active != 0 {
+		t.Errorf("expected no active sessions got %v", active)
+	}
+}
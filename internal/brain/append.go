@@ -0,0 +1,73 @@
+package brain
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/jedrw/brain/internal/patch"
+	"github.com/spf13/pflag"
+)
+
+// handleAppend appends stdin to a brainfile, or to the section under the
+// heading with the anchor given by --heading.
+func (b *Brain) handleAppend(s ssh.Session) error {
+	flags := pflag.NewFlagSet(APPEND, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	heading := flags.String("heading", "", "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%s requires 1 argument(s)", APPEND)
+	}
+
+	relPath := filepath.Clean(flags.Arg(0))
+	addition, err := io.ReadAll(s)
+	if err != nil {
+		return err
+	}
+
+	err = b.updateNode(s, relPath, func(data []byte) ([]byte, error) {
+		return appendSection(data, *heading, addition)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("appended to %s", relPath)
+	wish.Printf(s, "OK: appended to %s\n", relPath)
+
+	return nil
+}
+
+// handlePatch applies a unified diff read from stdin to a brainfile.
+func (b *Brain) handlePatch(s ssh.Session) error {
+	err := requireArgs(s, 1)
+	if err != nil {
+		return err
+	}
+
+	relPath := filepath.Clean(s.Command()[1])
+	diff, err := io.ReadAll(s)
+	if err != nil {
+		return err
+	}
+
+	err = b.updateNode(s, relPath, func(data []byte) ([]byte, error) {
+		return patch.Apply(data, diff)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("patched %s", relPath)
+	wish.Printf(s, "OK: patched %s\n", relPath)
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package brain
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/jedrw/brain/internal/config"
+)
+
+// testSession is an ssh.Session running command with stdin, the methods not
+// overridden panic if used.
+type testSession struct {
+	ssh.Session
+	command []string
+	stdin   *strings.Reader
+	stdout  bytes.Buffer
+	key     ssh.PublicKey
+}
+
+func newTestSession(stdin string, command ...string) *testSession {
+	return &testSession{command: command, stdin: strings.NewReader(stdin)}
+}
+
+func (s *testSession) Command() []string           { return s.command }
+func (s *testSession) Read(p []byte) (int, error)  { return s.stdin.Read(p) }
+func (s *testSession) Write(p []byte) (int, error) { return s.stdout.Write(p) }
+func (s *testSession) PublicKey() ssh.PublicKey    { return s.key }
+func (s *testSession) RemoteAddr() net.Addr        { return &net.TCPAddr{} }
+func (s *testSession) Environ() []string           { return nil }
+func (s *testSession) User() string                { return "" }
+
+func newTestBrain(t *testing.T, files map[string]string) *Brain {
+	t.Helper()
+	b := &Brain{
+		config: config.Config{Server: config.Server{ContentDir: t.TempDir()}},
+		tree:   &Tree{},
+		status: &status{},
+	}
+
+	for path, data := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(b.config.ContentDir, path)), 0770)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filepath.Join(b.config.ContentDir, path), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := b.getTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestAppendSequential(t *testing.T) {
+	b := newTestBrain(t, map[string]string{"log.md": "---\ntitle: Log\n---\n"})
+
+	// The tree isn't rebuilt between appends, as when they arrive before the
+	// updater has run
+	for _, line := range []string{"first\n", "second\n"} {
+		s := newTestSession(line, APPEND, "log.md")
+		err := b.handleAppend(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.config.ContentDir, "log.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "first\n") || !strings.HasSuffix(string(data), "second\n") {
+		t.Errorf("expected both appends got:\n%s", data)
+	}
+}
@@ -0,0 +1,297 @@
+package brain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// AssetsSuffix is appended to a brainfile's path, without its extension, to
+// name the directory holding its attachments.
+const AssetsSuffix = ".assets"
+
+var (
+	ErrInvalidAttachmentName = errors.New("attachment name must be a file name")
+	ErrInAssetsDir           = errors.New("brainfiles cannot be saved in an attachments directory")
+
+	srcRegexp = regexp.MustCompile(`src="([^"]*)"`)
+)
+
+// AssetsDir returns the attachments directory of the brainfile at relPath.
+func AssetsDir(relPath string) string {
+	return strings.TrimSuffix(relPath, ".md") + AssetsSuffix
+}
+
+// InAssetsDir reports whether relPath is within an attachments directory.
+func InAssetsDir(relPath string) bool {
+	for _, part := range strings.Split(filepath.Dir(relPath), string(filepath.Separator)) {
+		if strings.HasSuffix(part, AssetsSuffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listAttachments returns the paths of the files in the attachments
+// directory assetsDir, relative to baseDir.
+func listAttachments(baseDir, assetsDir string) ([]string, error) {
+	var attachments []string
+	err := filepath.WalkDir(filepath.Join(baseDir, assetsDir), func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(baseDir, filePath)
+		if err != nil {
+			return err
+		}
+
+		attachments = append(attachments, rel)
+		return nil
+	})
+
+	return attachments, err
+}
+
+// saveAttachment writes data as the attachment name of the brainfile at relPath.
+func (b *Brain) saveAttachment(s ssh.Session, relPath, name string, data []byte) (err error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	entry := newAuditEntry(s, ATTACH)
+	entry.Path = filepath.Join(AssetsDir(relPath), name)
+	defer func() { b.audit(entry, err) }()
+
+	if name == "" || filepath.Base(name) != name || !filepath.IsLocal(name) {
+		return fmt.Errorf("%w: %s", ErrInvalidAttachmentName, name)
+	}
+
+	notePath, err := b.contentPath(relPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(notePath)
+	if os.IsNotExist(err) || !strings.HasSuffix(relPath, ".md") || (err == nil && info.IsDir()) {
+		return fmt.Errorf("%w: %s", ErrNotExist, relPath)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	filePath, err := b.contentPath(entry.Path)
+	if err != nil {
+		return err
+	}
+
+	entry.HashBefore = hashFile(filePath)
+	err = os.MkdirAll(filepath.Dir(filePath), 0770)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	entry.HashAfter = hashContent(data)
+	b.update()
+
+	return nil
+}
+
+// AttachmentOf returns the brainfile an attachment at relPath belongs to
+// and its name, if relPath is directly within an attachments directory.
+func AttachmentOf(relPath string) (string, string, bool) {
+	dir, name := filepath.Split(relPath)
+	note, ok := strings.CutSuffix(filepath.Clean(dir), AssetsSuffix)
+	if !ok || name == "" {
+		return "", "", false
+	}
+
+	return note + ".md", name, true
+}
+
+// saveAttachmentPath writes data as the attachment at relPath.
+func (b *Brain) saveAttachmentPath(s ssh.Session, relPath string, data []byte) error {
+	note, name, ok := AttachmentOf(relPath)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidAttachmentName, relPath)
+	}
+
+	return b.saveAttachment(s, note, name, data)
+}
+
+// deleteAttachment removes the attachment at relPath along with any
+// directories left empty. Attachments that don't exist are already deleted,
+// such as when their brainfile was deleted first.
+func (b *Brain) deleteAttachment(s ssh.Session, relPath string) (err error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	entry := newAuditEntry(s, DELETE)
+	entry.Path = relPath
+	defer func() { b.audit(entry, err) }()
+
+	if !InAssetsDir(relPath) {
+		return fmt.Errorf("%w: %s", ErrInvalidAttachmentName, relPath)
+	}
+
+	filePath, err := b.contentPath(relPath)
+	if err != nil {
+		return err
+	}
+
+	entry.HashBefore = hashFile(filePath)
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = removeEmptyDirs(b.config.ContentDir, filepath.Dir(relPath))
+	if err != nil {
+		return err
+	}
+
+	b.update()
+
+	return nil
+}
+
+// showAttachment writes the attachment at relPath to the session as is.
+func (b *Brain) showAttachment(s ssh.Session, relPath string) error {
+	filePath, err := b.contentPath(relPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrNotExist, relPath)
+	} else if err != nil {
+		return err
+	}
+
+	log.Infof("sent %s for showing", relPath)
+	_, err = s.Write(data)
+	return err
+}
+
+// assetLinkRegexp matches links to the attachments directory of a brainfile
+// named base, capturing what precedes the directory name.
+func assetLinkRegexp(base string) *regexp.Regexp {
+	return regexp.MustCompile(`(\(<?|\]:\s*<?|(?:src|href)=")(\./)?` + regexp.QuoteMeta(base+AssetsSuffix+"/"))
+}
+
+// relinkAttachments checks the attachments of the brainfile at fromPathRel
+// can follow it to toPathRel and returns data with links to them updated,
+// and whether there are any to move with moveAttachments.
+func (b *Brain) relinkAttachments(fromPathRel, toPathRel string, data []byte) ([]byte, bool, error) {
+	_, err := os.Stat(filepath.Join(b.config.ContentDir, AssetsDir(fromPathRel)))
+	if os.IsNotExist(err) {
+		return data, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, err = os.Stat(filepath.Join(b.config.ContentDir, AssetsDir(toPathRel)))
+	if err == nil {
+		return nil, false, fmt.Errorf("%s already exists, move must be non-destructive", AssetsDir(toPathRel))
+	}
+
+	fromBase := filepath.Base(strings.TrimSuffix(fromPathRel, ".md"))
+	toBase := filepath.Base(strings.TrimSuffix(toPathRel, ".md"))
+	replacement := []byte("${1}${2}" + strings.ReplaceAll(toBase, "$", "$$") + AssetsSuffix + "/")
+	return assetLinkRegexp(fromBase).ReplaceAll(data, replacement), true, nil
+}
+
+// moveAttachments moves the attachments of the brainfile at fromPathRel to
+// follow it to toPathRel.
+func (b *Brain) moveAttachments(fromPathRel, toPathRel string) error {
+	toAssets := filepath.Join(b.config.ContentDir, AssetsDir(toPathRel))
+	err := os.MkdirAll(filepath.Dir(toAssets), 0770)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(filepath.Join(b.config.ContentDir, AssetsDir(fromPathRel)), toAssets)
+}
+
+// inlineImages replaces relative image sources in the HTML content of the
+// brainfile at relPath with data URIs so it can be viewed on its own.
+func inlineImages(content []byte, relPath, contentDir string) []byte {
+	return srcRegexp.ReplaceAllFunc(content, func(match []byte) []byte {
+		src, err := url.Parse(string(srcRegexp.FindSubmatch(match)[1]))
+		if err != nil || src.Scheme != "" || src.Host != "" || src.Path == "" {
+			return match
+		}
+
+		target := path.Join(path.Dir(filepath.ToSlash(relPath)), src.Path)
+		if !filepath.IsLocal(target) {
+			return match
+		}
+
+		data, err := os.ReadFile(filepath.Join(contentDir, target))
+		if err != nil {
+			return match
+		}
+
+		mediaType := mime.TypeByExtension(path.Ext(target))
+		if mediaType == "" {
+			mediaType = http.DetectContentType(data)
+		}
+
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, `src="data:%s;base64,`, mediaType)
+		buf.WriteString(base64.StdEncoding.EncodeToString(data))
+		buf.WriteByte('"')
+		return buf.Bytes()
+	})
+}
+
+func (b *Brain) handleAttach(s ssh.Session) error {
+	err := requireArgs(s, 2)
+	if err != nil {
+		return err
+	}
+
+	relPath := filepath.Clean(s.Command()[1])
+	name := s.Command()[2]
+	data, err := io.ReadAll(s)
+	if err != nil {
+		return err
+	}
+
+	err = b.saveAttachment(s, relPath, name, data)
+	if err != nil {
+		return err
+	}
+
+	link := &url.URL{Path: path.Join(filepath.Base(AssetsDir(relPath)), name)}
+	log.Infof("attached %s to %s", name, relPath)
+	wish.Printf(s, "OK: attached %s, link to it with ![%s](%s)\n", name, name, link)
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package brain
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jedrw/brain/internal/config"
+)
+
+func TestMoveAttachments(t *testing.T) {
+	contentDir := t.TempDir()
+	b := &Brain{config: config.Config{Server: config.Server{ContentDir: contentDir}}}
+	err := os.MkdirAll(filepath.Join(contentDir, "ops", "deploy.assets"), 0770)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(contentDir, "ops", "deploy.assets", "diagram.png"), []byte("png"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := "![diagram](deploy.assets/diagram.png) ![](<./deploy.assets/a b.png>)\n[ref]: deploy.assets/diagram.png\nnot-deploy.assets/x.png"
+	data, hasAttachments, err := b.relinkAttachments("ops/deploy.md", "runbooks/release.md", []byte(raw))
+	if err != nil || !hasAttachments {
+		t.Fatalf("expected attachments to move got %v", err)
+	}
+
+	expected := "![diagram](release.assets/diagram.png) ![](<./release.assets/a b.png>)\n[ref]: release.assets/diagram.png\nnot-deploy.assets/x.png"
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	err = b.moveAttachments("ops/deploy.md", "runbooks/release.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachments, err := listAttachments(contentDir, AssetsDir("runbooks/release.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(attachments) != 1 || attachments[0] != filepath.Join("runbooks", "release.assets", "diagram.png") {
+		t.Errorf("expected attachment to be moved got %v", attachments)
+	}
+}
+
+// auditEntries records the audit log of b, returning a function reading the
+// entries written so far.
+func auditEntries(t *testing.T, b *Brain) func() []AuditEntry {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	var err error
+	b.auditLog, err = openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.auditLog.Close() })
+
+	return func() []AuditEntry {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		var entries []AuditEntry
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry AuditEntry
+			err = json.Unmarshal(scanner.Bytes(), &entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return entries
+	}
+}
+
+func TestMoveNodeRollback(t *testing.T) {
+	b := newTestBrain(t, map[string]string{
+		"ops/deploy.md":                 "---\ntitle: Deploy\n---\n![](deploy.assets/diagram.png)\n",
+		"ops/deploy.assets/diagram.png": "png",
+	})
+
+	// A dangling symlink passes the existence check but can't be renamed over
+	err := os.MkdirAll(filepath.Join(b.config.ContentDir, "runbooks"), 0770)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Symlink("missing", filepath.Join(b.config.ContentDir, "runbooks", "release.assets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.moveNode(newTestSession(""), "ops/deploy.md", "runbooks/release.md")
+	if err == nil {
+		t.Fatal("expected moving the attachments to fail")
+	}
+
+	for path, exists := range map[string]bool{
+		"ops/deploy.md":                 true,
+		"ops/deploy.assets/diagram.png": true,
+		"runbooks/release.md":           false,
+	} {
+		_, err = os.Stat(filepath.Join(b.config.ContentDir, path))
+		if exists && err != nil {
+			t.Errorf("expected %s to be left in place got %v", path, err)
+		} else if !exists && !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed got %v", path, err)
+		}
+	}
+}
+
+func TestAttachmentOf(t *testing.T) {
+	for relPath, expected := range map[string][2]string{
+		"ops/deploy.assets/diagram.png":  {"ops/deploy.md", "diagram.png"},
+		"deploy.assets/a b.png":          {"deploy.md", "a b.png"},
+		"ops/deploy.assets/nested/x.png": {},
+		"ops/deploy.md":                  {},
+		"ops/deploy.assets.png":          {},
+	} {
+		note, name, ok := AttachmentOf(relPath)
+		if ok != (expected[0] != "") || note != expected[0] || name != expected[1] {
+			t.Errorf("%s: expected %v got %s %s %v", relPath, expected, note, name, ok)
+		}
+	}
+}
+
+func TestShowAndDeleteAttachment(t *testing.T) {
+	b := newTestBrain(t, map[string]string{
+		"ops/deploy.md":                 "---\ntitle: Deploy\n---\n",
+		"ops/deploy.assets/diagram.png": "\x89PNG\r\n",
+	})
+	entries := auditEntries(t, b)
+
+	s := newTestSession("", MANIFEST)
+	err := b.handleManifest(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest []ManifestEntry
+	err = json.Unmarshal(s.stdout.Bytes(), &manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := hashContent([]byte("\x89PNG\r\n"))
+	if len(manifest) != 1 || manifest[0].AttachmentHashes["ops/deploy.assets/diagram.png"] != expected {
+		t.Errorf("expected the manifest to include the attachment hash got %+v", manifest)
+	}
+
+	s = newTestSession("", SHOW, "ops/deploy.assets/diagram.png")
+	err = b.handleShow(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.stdout.String() != "\x89PNG\r\n" {
+		t.Errorf("expected the attachment as is got %q", s.stdout.String())
+	}
+
+	err = b.handleShow(newTestSession("", SHOW, "ops/deploy.assets/missing.png"))
+	if !errors.Is(err, ErrNotExist) {
+		t.Errorf("expected a missing attachment not to exist got %v", err)
+	}
+
+	err = b.handleDelete(newTestSession("", DELETE, "ops/deploy.assets/diagram.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = os.Stat(filepath.Join(b.config.ContentDir, "ops", "deploy.assets"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected the empty attachments directory to be removed got %v", err)
+	}
+
+	_, err = os.Stat(filepath.Join(b.config.ContentDir, "ops", "deploy.md"))
+	if err != nil {
+		t.Errorf("expected the brainfile to be kept got %v", err)
+	}
+
+	// Deleting an attachment again, such as after its brainfile, succeeds
+	err = b.deleteAttachment(newTestSession(""), "ops/deploy.assets/diagram.png")
+	if err != nil {
+		t.Errorf("expected deleting a missing attachment to succeed got %v", err)
+	}
+
+	audited := entries()
+	if len(audited) == 0 || audited[0].Action != DELETE || audited[0].Path != "ops/deploy.assets/diagram.png" || audited[0].HashBefore == "" {
+		t.Errorf("expected the delete to be audited got %+v", audited)
+	}
+}
@@ -0,0 +1,248 @@
+package brain
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/spf13/pflag"
+)
+
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	Action      string    `json:"action"`
+	Brain       string    `json:"brain,omitempty"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	Fingerprint string    `json:"fingerprint"`
+	Path        string    `json:"path,omitempty"`
+	OldPath     string    `json:"oldPath,omitempty"`
+	HashBefore  string    `json:"hashBefore,omitempty"`
+	HashAfter   string    `json:"hashAfter,omitempty"`
+	Result      string    `json:"result"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type AuditFilter struct {
+	// Brain is the name of the brain entries are for, empty for the default
+	// brain.
+	Brain string
+	Path  string
+	User  string
+	Since time.Time
+	Until time.Time
+}
+
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func openAuditLog(path string) (*auditLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0770)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auditLog{file: file}, nil
+}
+
+func (a *auditLog) write(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.file.Write(append(line, '\n'))
+	return err
+}
+
+func (a *auditLog) Close() error {
+	return a.file.Close()
+}
+
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the content hash of the file at path, or an empty string
+// if it does not exist.
+func hashFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return hashContent(data)
+}
+
+func newAuditEntry(s ssh.Session, action string) *AuditEntry {
+	return &AuditEntry{
+		Time:        time.Now().UTC(),
+		Action:      action,
+		RemoteAddr:  s.RemoteAddr().String(),
+		Fingerprint: fingerprint(s.PublicKey()),
+	}
+}
+
+func (b *Brain) audit(entry *AuditEntry, err error) {
+	if b.auditLog == nil {
+		return
+	}
+
+	entry.Brain = b.name
+	entry.Result = result(err)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	err = b.auditLog.write(*entry)
+	if err != nil {
+		log.Error("failed to write audit log", "err", err)
+	}
+}
+
+func (f AuditFilter) matches(entry AuditEntry) bool {
+	if entry.Brain != f.Brain {
+		return false
+	}
+
+	if f.Path != "" && !matchesPath(entry.Path, f.Path) && !matchesPath(entry.OldPath, f.Path) {
+		return false
+	}
+
+	if f.User != "" && entry.Fingerprint != f.User {
+		return false
+	}
+
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+
+	return true
+}
+
+// matchesPath reports whether path is target or is inside the directory target.
+func matchesPath(path, target string) bool {
+	target = filepath.Clean(target)
+	return path == target || strings.HasPrefix(path, target+string(os.PathSeparator))
+}
+
+func queryAudit(r io.Reader, w io.Writer, filter AuditFilter) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			log.Warn("skipping malformed audit entry", "err", err)
+			continue
+		}
+
+		if filter.matches(entry) {
+			fmt.Fprintf(w, "%s\n", scanner.Bytes())
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseTime parses either an absolute time (RFC3339 or a date) or a duration
+// before now such as "90m", "7d" or "2w".
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		n, ok := strings.CutSuffix(value, suffix)
+		if !ok {
+			continue
+		}
+
+		count, err := strconv.Atoi(n)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q", value)
+		}
+
+		return now.Add(-time.Duration(count) * unit), nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+func (b *Brain) handleAudit(s ssh.Session) error {
+	if b.auditLog == nil {
+		return fmt.Errorf("audit log is not enabled")
+	}
+
+	flags := pflag.NewFlagSet(AUDIT, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	path := flags.String("path", "", "")
+	user := flags.String("user", "", "")
+	since := flags.String("since", "", "")
+	until := flags.String("until", "", "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	filter := AuditFilter{Brain: b.name, Path: *path, User: *user}
+	filter.Since, err = parseTime(*since, now)
+	if err != nil {
+		return err
+	}
+
+	filter.Until, err = parseTime(*until, now)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(b.config.AuditLogPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	log.Info("queried audit log")
+	return queryAudit(file, s, filter)
+}
@@ -0,0 +1,75 @@
+package brain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"":                     {},
+		"7d":                   now.Add(-7 * 24 * time.Hour),
+		"2w":                   now.Add(-14 * 24 * time.Hour),
+		"90m":                  now.Add(-90 * time.Minute),
+		"2026-10-01":           time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		"2026-10-01T08:30:00Z": time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC),
+	}
+
+	for value, expected := range tests {
+		got, err := parseTime(value, now)
+		if err != nil {
+			t.Errorf("%q: %s", value, err)
+		}
+
+		if !got.Equal(expected) {
+			t.Errorf("%q: expected %s got %s", value, expected, got)
+		}
+	}
+
+	_, err := parseTime("yesterday", now)
+	if err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
+
+func TestQueryAudit(t *testing.T) {
+	log := strings.Join([]string{
+		`{"time":"2026-10-01T00:00:00Z","action":"new","fingerprint":"SHA256:a","path":"ops/deploy.md","result":"ok"}`,
+		`{"time":"2026-10-02T00:00:00Z","action":"move","fingerprint":"SHA256:b","path":"misc.md","oldPath":"ops/old.md","result":"ok"}`,
+		`{"time":"2026-10-03T00:00:00Z","action":"delete","fingerprint":"SHA256:a","path":"misc.md","result":"ok"}`,
+		`{"time":"2026-10-03T00:00:00Z","action":"edit","brain":"team","fingerprint":"SHA256:a","path":"ops/deploy.md","result":"ok"}`,
+		`not json`,
+	}, "\n")
+
+	tests := []struct {
+		filter   AuditFilter
+		expected []string
+	}{
+		{AuditFilter{Path: "ops"}, []string{"new", "move"}},
+		{AuditFilter{User: "SHA256:a"}, []string{"new", "delete"}},
+		{AuditFilter{Since: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)}, []string{"move", "delete"}},
+		{AuditFilter{Path: "misc.md", Until: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)}, []string{"move"}},
+		{AuditFilter{Brain: "team", Path: "ops"}, []string{"edit"}},
+	}
+
+	for _, test := range tests {
+		var sb strings.Builder
+		err := queryAudit(strings.NewReader(log), &sb, test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+		if len(lines) != len(test.expected) {
+			t.Fatalf("%+v: expected %d entries got %d:\n%s", test.filter, len(test.expected), len(lines), sb.String())
+		}
+
+		for i, action := range test.expected {
+			if !strings.Contains(lines[i], `"action":"`+action+`"`) {
+				t.Errorf("%+v: expected %s got %s", test.filter, action, lines[i])
+			}
+		}
+	}
+}
@@ -4,29 +4,58 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/anmitsu/go-shlex"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
+	bm "github.com/charmbracelet/wish/bubbletea"
 	"github.com/cockroachdb/cmux"
 	"github.com/jedrw/brain/internal/config"
+	"github.com/muesli/termenv"
 	"github.com/yuin/goldmark"
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
 )
 
 type Brain struct {
-	config    config.Config
-	ctx       context.Context
-	tree      *Tree
-	updater   chan<- struct{}
-	sshServer *ssh.Server
+	// name is empty for the default brain
+	name        string
+	brains      map[string]*Brain
+	config      config.Config
+	ctx         context.Context
+	tree        *Tree
+	status      *status
+	updater     chan<- struct{}
+	sshServer   *ssh.Server
+	adminServer *http.Server
+	auditLog    *auditLog
+	schema      Schema
+	gitMu       sync.Mutex
+	// writeMu serialises writes to brainfiles that read them first, and to
+	// attachments which move and are deleted with their brainfile
+	writeMu sync.Mutex
 }
 
+// defaultBrainLabel identifies the default brain in metrics
+const defaultBrainLabel = "default"
+
 var (
+	retryBackoffMin = time.Second
+	retryBackoffMax = 5 * time.Minute
+
+	brainNameRegexp     = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	reservedBrainNames  = []string{"brain", defaultBrainLabel}
 	ErrInvalidBrainNode = errors.New("invalid brain node")
 	ErrNotExist         = errors.New("node does not exist")
 	ErrNodeIsDir        = errors.New("node is directory")
@@ -35,67 +64,253 @@ var (
 			extension.Typographer,
 			meta.New(),
 		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
 	)
 )
 
 func NewBrain(ctx context.Context, config config.Config) (*Brain, error) {
+	err := checkGitDirs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := newBrain(ctx, "", config)
+	if err != nil {
+		return b, err
+	}
+
+	b.auditLog, err = openAuditLog(b.config.AuditLogPath)
+	if err != nil {
+		return b, err
+	}
+
+	b.brains = map[string]*Brain{}
+	contentDirs := map[string]string{filepath.Clean(config.ContentDir): defaultBrainLabel}
+	for _, named := range config.Brains {
+		err = validBrainName(named.Name)
+		if err != nil {
+			return b, err
+		}
+
+		if _, ok := b.brains[named.Name]; ok {
+			return b, fmt.Errorf("brain %q is configured more than once", named.Name)
+		}
+
+		if named.ContentDir == "" {
+			return b, fmt.Errorf("brain %q has no contentDir", named.Name)
+		}
+
+		other, ok := contentDirs[filepath.Clean(named.ContentDir)]
+		if ok {
+			return b, fmt.Errorf("brain %q shares its contentDir with %q", named.Name, other)
+		}
+
+		contentDirs[filepath.Clean(named.ContentDir)] = named.Name
+		namedConfig := config
+		namedConfig.Brains = nil
+		namedConfig.ContentDir = named.ContentDir
+		namedConfig.GitDir = named.GitDir
+		// Named brains don't inherit the default update task, which builds
+		// the default brain's site, or hooks which could leak their content
+		namedConfig.UpdateTasks = named.UpdateTasks
+		namedConfig.Hooks = named.Hooks
+		if len(named.AuthorizedKeys) > 0 {
+			namedConfig.AuthorizedKeys = named.AuthorizedKeys
+		}
+
+		nb, err := newBrain(ctx, named.Name, namedConfig)
+		if err != nil {
+			return b, fmt.Errorf("brain %q: %w", named.Name, err)
+		}
+
+		nb.auditLog = b.auditLog
+		b.brains[named.Name] = nb
+	}
+
+	b.start()
+	for _, nb := range b.brains {
+		nb.start()
+	}
+
+	return b, nil
+}
+
+// checkGitDirs checks no two brains share a git repository, which would
+// overwrite each other's history, and that none is within a content dir,
+// where it would be committed as content.
+func checkGitDirs(config config.Config) error {
+	contentDirs := map[string]string{defaultBrainLabel: config.ContentDir}
+	gitDirs := map[string]string{defaultBrainLabel: config.GitDir}
+	for _, named := range config.Brains {
+		contentDirs[named.Name] = named.ContentDir
+		gitDirs[named.Name] = named.GitDir
+	}
+
+	seen := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(gitDirs)) {
+		if gitDirs[name] == "" {
+			continue
+		}
+
+		gitDir := filepath.Clean(gitDirs[name])
+		if other, ok := seen[gitDir]; ok {
+			return fmt.Errorf("brain %q shares its gitDir with %q", name, other)
+		}
+
+		seen[gitDir] = name
+		for _, other := range slices.Sorted(maps.Keys(contentDirs)) {
+			if contentDirs[other] == "" {
+				continue
+			}
+
+			rel, err := filepath.Rel(filepath.Clean(contentDirs[other]), gitDir)
+			if err == nil && filepath.IsLocal(rel) {
+				return fmt.Errorf("brain %q has its gitDir in the contentDir of %q", name, other)
+			}
+		}
+	}
+
+	return nil
+}
+
+func newBrain(ctx context.Context, name string, config config.Config) (*Brain, error) {
 	b := &Brain{
+		name:   name,
 		ctx:    ctx,
 		config: config,
 		tree:   &Tree{},
+		status: &status{},
+		schema: Schema(config.Schema),
 	}
 
-	err := os.MkdirAll(b.config.ContentDir, 0770)
+	err := b.schema.Check()
 	if err != nil {
 		return b, err
 	}
 
+	err = os.MkdirAll(b.config.ContentDir, 0770)
+	if err != nil {
+		return b, err
+	}
+
+	return b, b.initGit()
+}
+
+// start begins serving the tree, building it for the first time.
+func (b *Brain) start() {
 	b.updater = b.Updater()
 	b.updater <- struct{}{}
+}
 
-	return b, nil
+func validBrainName(name string) error {
+	if !brainNameRegexp.MatchString(name) || slices.Contains(reservedBrainNames, name) {
+		return fmt.Errorf("invalid brain name %q", name)
+	}
+
+	return nil
+}
+
+// label identifies the brain in metrics.
+func (b *Brain) label() string {
+	if b.name == "" {
+		return defaultBrainLabel
+	}
+
+	return b.name
+}
+
+// authorizedKeys returns the keys allowed to connect to the server, those of
+// every brain it serves.
+func (b *Brain) authorizedKeys() []string {
+	keys := slices.Clone(b.config.AuthorizedKeys)
+	for _, nb := range b.brains {
+		for _, key := range nb.config.AuthorizedKeys {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
 }
 
+// getTree rebuilds the tree, only replacing the nodes being served if the
+// content dir itself could be read.
 func (b *Brain) getTree() error {
-	b.tree.mu.Lock()
-	defer b.tree.mu.Unlock()
-	var err error
-	b.tree.nodes, err = b.tree.getNodes(b.config.ContentDir, "")
+	errs := newBuildErrors()
+	nodes, err := b.tree.getNodes(b.config.ContentDir, "", errs)
 	if err != nil {
 		return err
 	}
 
+	b.tree.mu.Lock()
+	b.tree.nodes = nodes
+	b.tree.tasks = collectTasks(nodes)
+	b.tree.mu.Unlock()
+	b.status.treeUpdated(errs)
+	treeNodes.WithLabelValues(b.label()).Set(float64(countNodes(nodes)))
+	treeParseFailures.WithLabelValues(b.label()).Set(float64(len(errs.invalid)))
+
 	return nil
 }
 
+// update requests a tree rebuild without blocking if one is already pending.
+func (b *Brain) update() {
+	select {
+	case b.updater <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Brain) runUpdateTasks() {
+	taskErrs := map[string]string{}
+	for _, fnString := range b.config.UpdateTasks {
+		fnArgs, err := shlex.Split(fnString, true)
+		if err != nil {
+			log.Warnf("failed to parse updater task: %s", err)
+			taskErrs[fnString] = err.Error()
+			continue
+		}
+
+		fn := exec.Command(fnArgs[0], fnArgs[1:]...)
+		start := time.Now()
+		output, err := fn.CombinedOutput()
+		updateTaskDuration.WithLabelValues(fnString, result(err)).Observe(time.Since(start).Seconds())
+		if err != nil {
+			log.Warnf("failed to run updater task: %s\n%s", err, string(output))
+			taskErrs[fnString] = err.Error()
+			continue
+		}
+
+		log.Info("ran updater task. ")
+	}
+
+	b.status.tasksRan(taskErrs)
+}
+
 func (b *Brain) Updater() chan<- struct{} {
 	updateChan := make(chan struct{}, 1)
 	go func() {
-		select {
-		case <-b.ctx.Done():
-			return
-		default:
-			for range updateChan {
+		backoff := retryBackoffMin
+		for {
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-updateChan:
 				err := b.getTree()
 				if err != nil {
-					log.Fatal(fmt.Sprintf("could not update brain tree: %s", err))
+					log.Errorf("could not update brain tree, retrying in %s: %s", backoff, err)
+					b.status.treeFailed(err)
+					treeUpdateFailures.WithLabelValues(b.label()).Inc()
+					time.AfterFunc(backoff, b.update)
+					backoff = min(backoff*2, retryBackoffMax)
+					continue
 				}
 
-				for _, fnString := range b.config.UpdateTasks {
-					fnArgs, err := shlex.Split(fnString, true)
-					if err != nil {
-						log.Warnf("failed to parse updater task: %s", err)
-						continue
-					}
-
-					fn := exec.Command(fnArgs[0], fnArgs[1:]...)
-					output, err := fn.CombinedOutput()
-					if err != nil {
-						log.Warnf("failed to run updater task: %s\n%s", err, string(output))
-					}
-
-					log.Info("ran updater task. ")
-				}
+				backoff = retryBackoffMin
+				b.runUpdateTasks()
 			}
 		}
 	}()
@@ -113,13 +328,22 @@ func (b *Brain) Serve() error {
 
 	b.sshServer, err = newServer(
 		b.config.HostKeyPath,
-		b.config.AuthorizedKeys,
+		b.authorizedKeys(),
+		map[string]ssh.SubsystemHandler{
+			"sftp": b.sftpSubsystem,
+		},
+		bm.MiddlewareWithColorProfile(b.tuiHandler, termenv.ANSI),
 		b.sshHandler,
 	)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	err = b.serveAdmin()
+	if err != nil {
+		return err
+	}
+
 	go func() {
 		log.Info("starting ssh server")
 		if err := b.sshServer.Serve(listener); err != nil && err != ssh.ErrServerClosed && !errors.Is(err, cmux.ErrListenerClosed) {
@@ -132,6 +356,15 @@ func (b *Brain) Serve() error {
 }
 
 func (b *Brain) Shutdown(ctx context.Context) error {
+	if b.adminServer != nil {
+		log.Info("shutting down admin server")
+		err := b.adminServer.Shutdown(ctx)
+		if err != nil && err != http.ErrServerClosed {
+			log.Info("error from admin shutdown")
+			return err
+		}
+	}
+
 	log.Info("shutting down ssh server")
 	err := b.sshServer.Shutdown(ctx)
 	if err != nil && err != ssh.ErrServerClosed && !errors.Is(err, net.ErrClosed) {
@@ -139,5 +372,9 @@ func (b *Brain) Shutdown(ctx context.Context) error {
 		return err
 	}
 
+	if b.auditLog != nil {
+		return b.auditLog.Close()
+	}
+
 	return nil
 }
@@ -0,0 +1,61 @@
+package brain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+)
+
+// BrainEnv is the session environment variable clients select a named brain
+// with.
+const BrainEnv = "BRAIN"
+
+// sessionBrain returns the brain a session is addressed to, checking its key
+// may use it. Git commands address a named brain by repository, other
+// sessions by the BrainEnv environment variable.
+func (b *Brain) sessionBrain(s ssh.Session) (*Brain, error) {
+	target := b
+	name := b.requestedBrain(s)
+	if name != "" {
+		var ok bool
+		target, ok = b.brains[name]
+		if !ok {
+			return nil, fmt.Errorf("brain %q does not exist", name)
+		}
+	}
+
+	if !authorized(target.config.AuthorizedKeys, s.PublicKey()) {
+		return nil, fmt.Errorf("not authorized for brain %q", target.label())
+	}
+
+	return target, nil
+}
+
+func (b *Brain) requestedBrain(s ssh.Session) string {
+	command := s.Command()
+	if len(command) > 1 && strings.HasPrefix(command[0], "git-") {
+		repo := strings.TrimSuffix(strings.Trim(command[1], "/"), ".git")
+		if _, ok := b.brains[repo]; ok {
+			return repo
+		}
+	}
+
+	for _, env := range s.Environ() {
+		name, ok := strings.CutPrefix(env, BrainEnv+"=")
+		if ok {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// repoNames returns the repository names git clients may use for the brain.
+func (b *Brain) repoNames() []string {
+	if b.name == "" {
+		return gitRepoNames
+	}
+
+	return []string{b.name, b.name + ".git"}
+}
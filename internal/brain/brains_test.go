@@ -0,0 +1,76 @@
+package brain
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jedrw/brain/internal/config"
+)
+
+func TestNewBrainNamed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	base := config.Config{Server: config.Server{
+		ContentDir:     filepath.Join(dir, "docs"),
+		UpdateTasks:    []string{"true"},
+		AuthorizedKeys: []string{"default-key"},
+		Hooks:          []config.Hook{{URL: "http://team.example.com"}},
+	}}
+
+	named := base
+	named.Brains = []config.Named{
+		{Name: "team", ContentDir: filepath.Join(dir, "team")},
+		{Name: "oncall", ContentDir: filepath.Join(dir, "oncall"), UpdateTasks: []string{"true"}, AuthorizedKeys: []string{"oncall-key"}, Hooks: []config.Hook{{URL: "http://oncall.example.com"}}},
+	}
+
+	b, err := NewBrain(ctx, named)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	team := b.brains["team"]
+	if team == nil || team.config.ContentDir != filepath.Join(dir, "team") || len(team.config.UpdateTasks) != 0 || team.config.AuthorizedKeys[0] != "default-key" {
+		t.Fatalf("expected team to inherit keys but not update tasks got %+v", team)
+	}
+
+	if len(team.config.Hooks) != 0 || b.brains["oncall"].config.Hooks[0].URL != "http://oncall.example.com" {
+		t.Errorf("expected named brains to only have their own hooks got %+v and %+v", team.config.Hooks, b.brains["oncall"].config.Hooks)
+	}
+
+	keys := b.authorizedKeys()
+	if len(keys) != 2 || keys[0] != "default-key" || keys[1] != "oncall-key" {
+		t.Errorf("expected the keys of every brain got %v", keys)
+	}
+
+	tests := map[string][]config.Named{
+		"invalid brain name":         {{Name: "Team", ContentDir: filepath.Join(dir, "a")}},
+		`invalid brain name "brain"`: {{Name: "brain", ContentDir: filepath.Join(dir, "a")}},
+		"more than once":             {{Name: "a", ContentDir: filepath.Join(dir, "a")}, {Name: "a", ContentDir: filepath.Join(dir, "b")}},
+		"has no contentDir":          {{Name: "a"}},
+		`shares its contentDir with`: {{Name: "a", ContentDir: base.ContentDir + "/"}},
+		`"b" shares its gitDir with "a"`: {
+			{Name: "a", ContentDir: filepath.Join(dir, "a"), GitDir: filepath.Join(dir, "shared.git")},
+			{Name: "b", ContentDir: filepath.Join(dir, "b"), GitDir: filepath.Join(dir, "shared.git/")},
+		},
+		`"a" has its gitDir in the contentDir of "b"`: {
+			{Name: "a", ContentDir: filepath.Join(dir, "a"), GitDir: filepath.Join(dir, "b", ".git")},
+			{Name: "b", ContentDir: filepath.Join(dir, "b")},
+		},
+		`"a" has its gitDir in the contentDir of "default"`: {
+			{Name: "a", ContentDir: filepath.Join(dir, "a"), GitDir: base.ContentDir},
+		},
+	}
+
+	for expected, brains := range tests {
+		invalid := base
+		invalid.Brains = brains
+		_, err := NewBrain(ctx, invalid)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q error got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package brain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/jedrw/brain/internal/archive"
+	"github.com/spf13/pflag"
+)
+
+const ManifestFile = "manifest.json"
+
+// importLimits bound an archive being imported as it is held in memory, with
+// each file limited as it would be over SFTP.
+var importLimits = archive.Limits{Archive: 256 << 20, File: maxSFTPFileSize}
+
+// handleExport streams the brain, or the subtree given, as an archive with a
+// manifest or as a single HTML document.
+func (b *Brain) handleExport(s ssh.Session) error {
+	flags := pflag.NewFlagSet(EXPORT, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	formatFlag := flags.String("format", string(archive.TarGz), "")
+	title := flags.String("title", "", "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	root := ""
+	if flags.NArg() > 0 && filepath.Clean(flags.Arg(0)) != "." {
+		root = filepath.Clean(flags.Arg(0))
+	}
+
+	nodes := b.tree.files(root)
+	if root != "" && len(nodes) == 0 {
+		return fmt.Errorf("%w: %s", ErrNotExist, root)
+	}
+
+	if *formatFlag == ExportHTML {
+		if *title == "" {
+			*title = "Brain"
+			if root != "" {
+				*title = root
+			}
+		}
+
+		log.Info("exporting brain", "root", root, "format", ExportHTML, "brainfiles", len(nodes))
+		return writeHTML(s, *title, root, b.config.ContentDir, nodes)
+	}
+
+	format, err := archive.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	manifest := []ManifestEntry{}
+	for _, node := range nodes {
+		manifest = append(manifest, newManifestEntry(node))
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	log.Info("exporting brain", "root", root, "format", format, "brainfiles", len(nodes))
+	w, err := archive.NewWriter(s, format)
+	if err != nil {
+		return err
+	}
+
+	err = w.Add(ManifestFile, time.Now(), int64(len(manifestData)), bytes.NewReader(manifestData))
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		err = w.Add(node.Path, node.ModTime, int64(len(node.Raw)), bytes.NewReader(node.Raw))
+		if err != nil {
+			return err
+		}
+
+		for _, attachment := range node.Attachments {
+			err = b.exportAttachment(w, attachment)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.Close()
+}
+
+// exportAttachment streams the attachment at relPath from disk to w.
+func (b *Brain) exportAttachment(w *archive.Writer, relPath string) error {
+	f, err := os.Open(filepath.Join(b.config.ContentDir, relPath))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	return w.Add(relPath, info.ModTime(), info.Size(), f)
+}
+
+// importError lists what an import restored before failing to restore the
+// rest.
+type importError struct {
+	restored []string
+	failed   []error
+}
+
+func (e *importError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "failed to restore %d of %d file(s):", len(e.failed), len(e.failed)+len(e.restored))
+	for _, err := range e.failed {
+		fmt.Fprintf(&sb, "\n  %s", err)
+	}
+
+	if len(e.restored) > 0 {
+		sb.WriteString("\nrestored:")
+		for _, relPath := range e.restored {
+			fmt.Fprintf(&sb, "\n  %s", relPath)
+		}
+	}
+
+	return sb.String()
+}
+
+// handleImportArchive restores an archive created by EXPORT. Every brainfile
+// is validated before any are saved, and each keeps the modification time
+// recorded in the manifest. Attachments are restored after their brainfiles.
+// If any fail to save the rest are still restored and both are reported.
+// Archives exceeding importLimits are rejected before anything is saved.
+func (b *Brain) handleImportArchive(s ssh.Session) error {
+	flags := pflag.NewFlagSet(IMPORT_ARCHIVE, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	formatFlag := flags.String("format", string(archive.TarGz), "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	format, err := archive.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	files, err := archive.Read(s, format, importLimits)
+	if err != nil {
+		return fmt.Errorf("could not read archive: %w", err)
+	}
+
+	modTimes := map[string]time.Time{}
+	var brainfiles, attachments []archive.File
+	var errs []error
+	for _, file := range files {
+		if file.Path == ManifestFile {
+			var manifest []ManifestEntry
+			err := json.Unmarshal(file.Data, &manifest)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", ManifestFile, err)
+			}
+
+			for _, entry := range manifest {
+				modTimes[entry.Path] = entry.ModTime
+			}
+
+			continue
+		}
+
+		if InAssetsDir(file.Path) {
+			attachments = append(attachments, file)
+			continue
+		}
+
+		if !strings.HasSuffix(file.Path, ".md") {
+			continue
+		}
+
+		_, err := b.contentPath(file.Path)
+		if err == nil {
+			var node Node
+			node, err = NewNodeFromBytes(file.Data)
+			if err == nil {
+				err = b.schema.Validate(file.Path, node)
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.Path, err))
+		}
+
+		brainfiles = append(brainfiles, file)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// Saving continues past failures so everything that can be is restored,
+	// reporting what was and wasn't
+	importErr := &importError{}
+	restore := func(file archive.File, modTime time.Time, save func() error) {
+		err := save()
+		if err != nil {
+			importErr.failed = append(importErr.failed, fmt.Errorf("%s: %w", file.Path, err))
+			return
+		}
+
+		importErr.restored = append(importErr.restored, file.Path)
+		err = os.Chtimes(filepath.Join(b.config.ContentDir, file.Path), modTime, modTime)
+		if err != nil {
+			log.Warn("failed to set modification time", "path", file.Path, "err", err)
+		}
+	}
+
+	for _, file := range brainfiles {
+		modTime, ok := modTimes[file.Path]
+		if !ok || modTime.IsZero() {
+			modTime = file.ModTime
+		}
+
+		restore(file, modTime, func() error {
+			return b.restoreNode(s, file.Path, file.Data)
+		})
+	}
+
+	for _, file := range attachments {
+		restore(file, file.ModTime, func() error {
+			return b.saveAttachmentPath(s, file.Path, file.Data)
+		})
+	}
+
+	if len(importErr.failed) > 0 {
+		log.Error("archive import failed partway", "restored", len(importErr.restored), "failed", len(importErr.failed))
+		return importErr
+	}
+
+	log.Infof("imported %d brainfile(s) and %d attachment(s) from archive", len(brainfiles), len(attachments))
+	wish.Printf(s, "OK: imported %d brainfile(s) and %d attachment(s)\n", len(brainfiles), len(attachments))
+	return nil
+}
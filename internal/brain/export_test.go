@@ -0,0 +1,90 @@
+package brain
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jedrw/brain/internal/archive"
+)
+
+func TestExportAndImportArchive(t *testing.T) {
+	b := newTestBrain(t, map[string]string{
+		"a.md":                 "---\ntitle: A\n---\n![](a.assets/photo.jpg)\n",
+		"a.assets/photo.jpg":   "jpg",
+		"ops/b.md":             "---\ntitle: B\n---\n",
+		"ops/b.assets/log.txt": "log",
+	})
+
+	s := newTestSession("", EXPORT, "--format", string(archive.Zip))
+	err := b.handleExport(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exported := s.stdout.Bytes()
+	files, err := archive.Read(bytes.NewReader(exported), archive.Zip, importLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+
+	expected := []string{ManifestFile, "a.md", "a.assets/photo.jpg", "ops/b.md", "ops/b.assets/log.txt"}
+	if strings.Join(paths, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %v got %v", expected, paths)
+	}
+
+	var manifest []ManifestEntry
+	err = json.Unmarshal(files[0].Data, &manifest)
+	if err != nil || len(manifest) != 2 {
+		t.Errorf("expected a manifest of both brainfiles got %+v %v", manifest, err)
+	}
+
+	if string(files[2].Data) != "jpg" {
+		t.Errorf("expected attachment to be exported as is got %q", files[2].Data)
+	}
+
+	restored := newTestBrain(t, nil)
+	s = newTestSession(string(exported), IMPORT_ARCHIVE, "--format", string(archive.Zip))
+	err = restored.handleImportArchive(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(restored.config.ContentDir, "ops", "b.assets", "log.txt"))
+	if err != nil || string(data) != "log" {
+		t.Errorf("expected attachment to be restored got %q %v", data, err)
+	}
+
+	// A directory in the way of a brainfile fails it and its attachment,
+	// leaving the rest restored
+	partial := newTestBrain(t, nil)
+	err = os.MkdirAll(filepath.Join(partial.config.ContentDir, "ops", "b.md", "x"), 0770)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s = newTestSession(string(exported), IMPORT_ARCHIVE, "--format", string(archive.Zip))
+	err = partial.handleImportArchive(s)
+	importErr, ok := err.(*importError)
+	if !ok {
+		t.Fatalf("expected an import error got %v", err)
+	}
+
+	if strings.Join(importErr.restored, " ") != "a.md a.assets/photo.jpg" || len(importErr.failed) != 2 {
+		t.Errorf("expected a.md and its attachment to be restored got %v, failed %v", importErr.restored, importErr.failed)
+	}
+
+	for _, expected := range []string{"failed to restore 2 of 4 file(s)", "ops/b.md: ", "ops/b.assets/log.txt: ", "restored:\n  a.md\n  a.assets/photo.jpg"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package brain
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v2"
+)
+
+var frontmatterDelimiter = []byte("---")
+
+// SplitFrontmatter separates a brainfile into its frontmatter, preserving key
+// order, and body. Data without frontmatter returns an empty MapSlice and the
+// data unchanged.
+func SplitFrontmatter(data []byte) (yaml.MapSlice, []byte, error) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines) == 0 || !bytes.Equal(bytes.TrimSpace(lines[0]), frontmatterDelimiter) {
+		return yaml.MapSlice{}, data, nil
+	}
+
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		if bytes.Equal(bytes.TrimSpace(line), frontmatterDelimiter) {
+			var frontmatter yaml.MapSlice
+			err := yaml.Unmarshal(data[len(lines[0]):offset], &frontmatter)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %s", ErrInvalidBrainNode, err)
+			}
+
+			if frontmatter == nil {
+				frontmatter = yaml.MapSlice{}
+			}
+
+			return frontmatter, data[offset+len(line):], nil
+		}
+
+		offset += len(line)
+	}
+
+	return yaml.MapSlice{}, data, nil
+}
+
+// JoinFrontmatter reassembles a brainfile from its frontmatter and body.
+func JoinFrontmatter(frontmatter yaml.MapSlice, body []byte) ([]byte, error) {
+	if len(frontmatter) == 0 {
+		return body, nil
+	}
+
+	out, err := yaml.Marshal(frontmatter)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(frontmatterDelimiter)
+	buf.WriteByte('\n')
+	buf.Write(out)
+	buf.Write(frontmatterDelimiter)
+	buf.WriteByte('\n')
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// GetFrontmatter returns the value of key and whether it is set.
+func GetFrontmatter(frontmatter yaml.MapSlice, key string) (any, bool) {
+	for _, item := range frontmatter {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// SetFrontmatter sets key to value, replacing it in place if already present
+// or appending it otherwise.
+func SetFrontmatter(frontmatter yaml.MapSlice, key string, value any) yaml.MapSlice {
+	for i, item := range frontmatter {
+		if item.Key == key {
+			frontmatter[i].Value = value
+			return frontmatter
+		}
+	}
+
+	return append(frontmatter, yaml.MapItem{Key: key, Value: value})
+}
+
+// frontmatterEntry returns the range of lines holding the top level entry
+// for key in the frontmatter of lines, including its continuation lines, and
+// the line closing the frontmatter. start is -1 if key is not set and end is
+// -1 if there is no frontmatter.
+func frontmatterEntry(lines [][]byte, key string) (int, int, int) {
+	if len(lines) == 0 || !bytes.Equal(bytes.TrimSpace(lines[0]), frontmatterDelimiter) {
+		return -1, -1, -1
+	}
+
+	start, end := -1, -1
+	prefix := []byte(key + ":")
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		if bytes.Equal(bytes.TrimSpace(line), frontmatterDelimiter) {
+			if start != -1 && end == -1 {
+				end = i
+			}
+
+			return start, end, i
+		}
+
+		continuation := len(line) > 0 && (line[0] == ' ' || line[0] == '\t' || line[0] == '-')
+		switch {
+		case start != -1 && end == -1 && !continuation:
+			end = i
+		case start == -1 && bytes.HasPrefix(line, prefix):
+			start = i
+		}
+	}
+
+	return -1, -1, -1
+}
+
+// frontmatterText returns the text of the top level entry for key in the
+// frontmatter of data, or nil if it is not set.
+func frontmatterText(data []byte, key string) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	start, end, _ := frontmatterEntry(lines, key)
+	if start == -1 {
+		return nil
+	}
+
+	return bytes.Join(lines[start:end], nil)
+}
+
+// setFrontmatterText replaces the top level entry for key in the frontmatter
+// of data with text, or adds it to the end of the frontmatter, leaving the
+// rest of data as it is.
+func setFrontmatterText(data []byte, key string, text []byte) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	start, end, closing := frontmatterEntry(lines, key)
+	if closing == -1 {
+		return append(append(append([]byte("---\n"), text...), "---\n"...), data...)
+	}
+
+	if start == -1 {
+		start, end = closing, closing
+	}
+
+	var buf bytes.Buffer
+	buf.Write(bytes.Join(lines[:start], nil))
+	buf.Write(text)
+	buf.Write(bytes.Join(lines[end:], nil))
+	return buf.Bytes()
+}
+
+// setFrontmatterValue is setFrontmatterText for a value marshalled as YAML.
+func setFrontmatterValue(data []byte, key string, value any) ([]byte, error) {
+	text, err := yaml.Marshal(yaml.MapSlice{{Key: key, Value: value}})
+	if err != nil {
+		return nil, err
+	}
+
+	return setFrontmatterText(data, key, text), nil
+}
+
+// SetTitleAndTags sets the title of a brainfile if title is not empty and
+// adds tags to any it already has, adding frontmatter if it has none.
+func SetTitleAndTags(data []byte, title string, tags []string) ([]byte, error) {
+	frontmatter, body, err := SplitFrontmatter(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if title != "" {
+		frontmatter = SetFrontmatter(frontmatter, "title", title)
+	}
+
+	if len(tags) > 0 {
+		value, _ := GetFrontmatter(frontmatter, "tags")
+		existing := parseTags(value)
+		for _, tag := range tags {
+			if !slices.Contains(existing, tag) {
+				existing = append(existing, tag)
+			}
+		}
+
+		frontmatter = SetFrontmatter(frontmatter, "tags", existing)
+	}
+
+	return JoinFrontmatter(frontmatter, body)
+}
@@ -0,0 +1,38 @@
+package brain
+
+import "testing"
+
+func TestSetTitleAndTags(t *testing.T) {
+	for _, test := range []struct {
+		data     string
+		title    string
+		tags     []string
+		expected string
+	}{
+		{
+			data:     "Just a body.\n",
+			title:    "Note",
+			tags:     []string{"ops"},
+			expected: "---\ntitle: Note\ntags:\n- ops\n---\nJust a body.\n",
+		},
+		{
+			data:     "---\ntitle: Old\nowner: jed\ntags: ops, db\n---\nBody\n",
+			tags:     []string{"db", "runbook"},
+			expected: "---\ntitle: Old\nowner: jed\ntags:\n- ops\n- db\n- runbook\n---\nBody\n",
+		},
+		{
+			data:     "---\ntitle: Old\n---\nBody\n",
+			title:    "New",
+			expected: "---\ntitle: New\n---\nBody\n",
+		},
+	} {
+		data, err := SetTitleAndTags([]byte(test.data), test.title, test.tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != test.expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", test.expected, data)
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package brain
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+)
+
+const (
+	GIT_UPLOAD_PACK  string = "git-upload-pack"
+	GIT_RECEIVE_PACK string = "git-receive-pack"
+
+	gitBranch   = "main"
+	gitRef      = "refs/heads/" + gitBranch
+	gitZeroHash = "0000000000000000000000000000000000000000"
+)
+
+var (
+	ErrGitDisabled   = errors.New("git is not enabled on this server")
+	ErrInvalidRepo   = errors.New("repository not found, use \"brain\"")
+	ErrNotAttachment = errors.New("only brainfiles and attachments in their " + AssetsSuffix + " directories can be pushed")
+
+	gitRepoNames = []string{"brain", "brain.git"}
+)
+
+const preReceiveHook = `#!/bin/sh
+exec %q hook pre-receive
+`
+
+// git runs a git command against the brain repository using the content dir
+// as its work tree.
+func (b *Brain) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{
+		"--git-dir", b.config.GitDir,
+		"--work-tree", b.config.ContentDir,
+		"-c", "user.name=brain",
+		"-c", "user.email=brain@localhost",
+	}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return string(out), fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// initGit creates the bare repository mirroring the content dir if required
+// and installs the hook that validates pushes.
+func (b *Brain) initGit() error {
+	if b.config.GitDir == "" {
+		return nil
+	}
+
+	_, err := os.Stat(filepath.Join(b.config.GitDir, "HEAD"))
+	if os.IsNotExist(err) {
+		out, err := exec.Command("git", "init", "--bare", "--initial-branch", gitBranch, b.config.GitDir).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("could not init git repository: %w: %s", err, out)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	hookPath := filepath.Join(b.config.GitDir, "hooks", "pre-receive")
+	err = os.MkdirAll(filepath.Dir(hookPath), 0770)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(hookPath, fmt.Appendf(nil, preReceiveHook, executable), 0755)
+}
+
+// commitContent commits any changes made to the content dir outside of git so
+// the repository mirrors it.
+func (b *Brain) commitContent(message string) error {
+	_, err := b.git("add", "--all")
+	if err != nil {
+		return err
+	}
+
+	status, err := b.git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+
+	if status == "" {
+		return nil
+	}
+
+	_, err = b.git("commit", "--quiet", "--message", message)
+	return err
+}
+
+func (b *Brain) head() string {
+	rev, err := b.git("rev-parse", "--verify", "--quiet", gitRef)
+	if err != nil {
+		return ""
+	}
+
+	return rev
+}
+
+func (b *Brain) gitRepo(s ssh.Session) error {
+	if b.config.GitDir == "" {
+		return ErrGitDisabled
+	}
+
+	err := requireArgs(s, 1)
+	if err != nil {
+		return err
+	}
+
+	repo := strings.Trim(s.Command()[1], "/")
+	for _, name := range b.repoNames() {
+		if repo == name {
+			return nil
+		}
+	}
+
+	if b.name != "" {
+		return fmt.Errorf("repository not found, use %q", b.name)
+	}
+
+	return ErrInvalidRepo
+}
+
+func (b *Brain) runGitService(s ssh.Session, service string) error {
+	cmd := exec.Command(service, b.config.GitDir)
+	// The pre-receive hook validates pushes against the schema
+	cmd.Env = append(os.Environ(), b.schema.env())
+	cmd.Stdin = s
+	cmd.Stdout = s
+	cmd.Stderr = s.Stderr()
+	return cmd.Run()
+}
+
+func (b *Brain) handleUploadPack(s ssh.Session) error {
+	err := b.gitRepo(s)
+	if err != nil {
+		return err
+	}
+
+	b.gitMu.Lock()
+	defer b.gitMu.Unlock()
+
+	err = b.commitContent("Sync brain content")
+	if err != nil {
+		return err
+	}
+
+	log.Info("serving git fetch")
+	return b.runGitService(s, GIT_UPLOAD_PACK)
+}
+
+func (b *Brain) handleReceivePack(s ssh.Session) error {
+	err := b.gitRepo(s)
+	if err != nil {
+		return err
+	}
+
+	b.gitMu.Lock()
+	defer b.gitMu.Unlock()
+
+	err = b.commitContent("Sync brain content")
+	if err != nil {
+		return err
+	}
+
+	oldHead := b.head()
+	err = b.runGitService(s, GIT_RECEIVE_PACK)
+	if err != nil {
+		return err
+	}
+
+	newHead := b.head()
+	if newHead == oldHead {
+		return nil
+	}
+
+	log.Info("received git push", "from", oldHead, "to", newHead)
+	return b.applyPush(s, oldHead, newHead)
+}
+
+// gitChange is a file changed between two commits.
+type gitChange struct {
+	status  byte
+	path    string
+	oldPath string
+}
+
+// parseNameStatus parses the output of git diff --name-status -z.
+func parseNameStatus(out string) []gitChange {
+	var changes []gitChange
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		change := gitChange{status: fields[i][0], path: fields[i+1]}
+		if change.status == 'R' || change.status == 'C' {
+			if i+2 >= len(fields) {
+				break
+			}
+
+			i++
+			change.oldPath, change.path = change.path, fields[i+1]
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// applyPush checks out a pushed commit into the content dir, recording each
+// change as if it had been made with the equivalent command. oldHead is
+// empty for the first push to a brain. If it can't be checked out the push
+// is undone so the repository keeps mirroring the content dir.
+//
+// Pushed brainfiles are stamped like any other save, and the stamps
+// committed on top of the push, so pushers pull them before pushing again.
+func (b *Brain) applyPush(s ssh.Session, oldHead, newHead string) error {
+	oldTree := oldHead
+	if oldHead == "" {
+		emptyTree, err := b.git("hash-object", "-t", "tree", os.DevNull)
+		if err != nil {
+			return err
+		}
+
+		oldTree = emptyTree
+	}
+
+	out, err := b.git("diff", "--name-status", "-z", "--find-renames", oldTree, newHead)
+	if err != nil {
+		return err
+	}
+
+	changes := parseNameStatus(out)
+	b.writeMu.Lock()
+	oldNodes := map[string]*Node{}
+	oldHashes := map[string]string{}
+	oldData := map[string][]byte{}
+	for _, change := range changes {
+		if isAttachmentChange(change) {
+			oldPath := change.path
+			if change.status == 'R' {
+				oldPath = change.oldPath
+			}
+
+			oldHashes[oldPath] = hashFile(filepath.Join(b.config.ContentDir, oldPath))
+			continue
+		}
+
+		switch change.status {
+		case 'M':
+			oldData[change.path], _ = os.ReadFile(filepath.Join(b.config.ContentDir, change.path))
+		case 'D':
+			oldNodes[change.path], _ = b.tree.Find(change.path)
+		case 'R':
+			oldNodes[change.oldPath], _ = b.tree.Find(change.oldPath)
+			oldData[change.path], _ = os.ReadFile(filepath.Join(b.config.ContentDir, change.oldPath))
+		}
+	}
+
+	_, err = b.git("read-tree", "-m", "-u", oldTree, newHead)
+	if err != nil {
+		b.writeMu.Unlock()
+		return errors.Join(err, b.resetHead(oldHead, newHead))
+	}
+
+	stamped := false
+	for _, change := range changes {
+		if isAttachmentChange(change) || change.status == 'D' {
+			continue
+		}
+
+		err = b.stampPushed(s, change.path, oldData[change.path])
+		if err != nil {
+			log.Warn("failed to stamp pushed brainfile", "path", change.path, "err", err)
+			continue
+		}
+
+		stamped = true
+	}
+
+	if stamped {
+		err = b.commitContent("Stamp pushed brainfiles")
+		if err != nil {
+			log.Warn("failed to commit stamped brainfiles", "err", err)
+		}
+	}
+
+	b.update()
+	b.writeMu.Unlock()
+
+	for _, change := range changes {
+		if isAttachmentChange(change) {
+			b.recordAttachmentChange(s, change, oldHashes)
+			continue
+		}
+
+		switch change.status {
+		case 'A', 'M', 'C':
+			eventType := NodeUpdated
+			if change.status != 'M' {
+				eventType = NodeCreated
+			}
+
+			b.recordChange(s, NEW, eventType, change.path, "", nil)
+		case 'D':
+			b.recordChange(s, DELETE, NodeDeleted, change.path, "", oldNodes[change.path])
+		case 'R':
+			b.recordChange(s, MOVE, NodeMoved, change.path, change.oldPath, oldNodes[change.oldPath])
+		}
+	}
+
+	return nil
+}
+
+// resetHead moves the branch back from newHead to oldHead, deleting it if
+// there was no oldHead.
+func (b *Brain) resetHead(oldHead, newHead string) error {
+	if oldHead == "" {
+		_, err := b.git("update-ref", "-d", gitRef, newHead)
+		return err
+	}
+
+	_, err := b.git("update-ref", gitRef, oldHead, newHead)
+	return err
+}
+
+// stampPushed stamps the pushed brainfile at relPath, which was existing
+// before the push or nil if it is new.
+func (b *Brain) stampPushed(s ssh.Session, relPath string, existing []byte) error {
+	filePath := filepath.Join(b.config.ContentDir, relPath)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	data, err = stamp(data, existing, fingerprint(s.PublicKey()), time.Now())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// recordChange audits and emits an event for a change already applied to the
+// content dir.
+func (b *Brain) recordChange(s ssh.Session, action string, eventType EventType, relPath, oldPath string, oldNode *Node) {
+	entry := newAuditEntry(s, action)
+	entry.Path = relPath
+	entry.OldPath = oldPath
+	if oldNode != nil {
+		entry.HashBefore = hashContent(oldNode.Raw)
+	}
+
+	node := oldNode
+	if eventType != NodeDeleted {
+		filePath := filepath.Join(b.config.ContentDir, relPath)
+		entry.HashAfter = hashFile(filePath)
+		newNode, err := NewNodeFromFile(filePath)
+		if err == nil {
+			node = &newNode
+		}
+	}
+
+	b.audit(entry, nil)
+	event := newEvent(eventType, s, node, relPath)
+	event.OldPath = oldPath
+	b.emit(event)
+}
+
+func isAttachmentChange(change gitChange) bool {
+	return InAssetsDir(change.path) || (change.status == 'R' && InAssetsDir(change.oldPath))
+}
+
+// recordAttachmentChange audits a change to an attachment already applied to
+// the content dir. Attachments aren't brainfiles so no event is emitted.
+func (b *Brain) recordAttachmentChange(s ssh.Session, change gitChange, oldHashes map[string]string) {
+	action := ATTACH
+	switch change.status {
+	case 'D':
+		action = DELETE
+	case 'R':
+		action = MOVE
+	}
+
+	entry := newAuditEntry(s, action)
+	entry.Path = change.path
+	entry.OldPath = change.oldPath
+	entry.HashBefore = oldHashes[change.path]
+	if change.status == 'R' {
+		entry.HashBefore = oldHashes[change.oldPath]
+	}
+
+	if change.status != 'D' {
+		entry.HashAfter = hashFile(filepath.Join(b.config.ContentDir, change.path))
+	}
+
+	b.audit(entry, nil)
+}
+
+// validatePushedFile checks filePath in rev is a valid brainfile, or an
+// attachment in the attachments directory of one.
+func validatePushedFile(rev, filePath string, schema Schema) error {
+	if !strings.HasSuffix(filePath, ".md") {
+		if !InAssetsDir(filePath) {
+			return fmt.Errorf("%s: %w", filePath, ErrNotAttachment)
+		}
+
+		return nil
+	}
+
+	if InAssetsDir(filePath) {
+		return fmt.Errorf("%s: %w", filePath, ErrInAssetsDir)
+	}
+
+	data, err := exec.Command("git", "cat-file", "blob", rev+":"+filePath).Output()
+	if err != nil {
+		return err
+	}
+
+	node, err := NewNodeFromBytes(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	return schema.Validate(filePath, node)
+}
+
+// ValidatePush reads pre-receive hook input and returns an error if any
+// updated ref is not the brain branch or contains an invalid brainfile.
+func ValidatePush(r io.Reader, schema Schema) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+
+		oldRev, newRev, ref := fields[0], fields[1], fields[2]
+		if ref != gitRef {
+			return fmt.Errorf("only %s can be pushed", gitBranch)
+		}
+
+		if newRev == gitZeroHash {
+			return fmt.Errorf("%s cannot be deleted", gitBranch)
+		}
+
+		args := []string{"diff", "--name-only", "-z", "--diff-filter=ACMR", oldRev, newRev}
+		if oldRev == gitZeroHash {
+			args = []string{"ls-tree", "-r", "-z", "--name-only", newRev}
+		}
+
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return err
+		}
+
+		for _, filePath := range strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+			if filePath == "" {
+				continue
+			}
+
+			err = validatePushedFile(newRev, filePath, schema)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,184 @@
+package brain
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@localhost"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s\n%s", args[0], err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, data := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0770)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filepath.Join(dir, path), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestApplyPush(t *testing.T) {
+	b := newTestBrain(t, nil)
+	entries := auditEntries(t, b)
+	b.config.GitDir = filepath.Join(t.TempDir(), "brain.git")
+	err := b.initGit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hook runs brain itself, which isn't what's being tested
+	err = os.Remove(filepath.Join(b.config.GitDir, "hooks", "pre-receive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	work := t.TempDir()
+	runGit(t, work, "init", "--quiet", "--initial-branch", gitBranch)
+	push := func(message string) {
+		t.Helper()
+		runGit(t, work, "add", "--all")
+		runGit(t, work, "commit", "--quiet", "--message", message)
+		oldHead := b.head()
+		runGit(t, work, "push", "--quiet", b.config.GitDir, gitBranch)
+		err := b.applyPush(newTestSession(""), oldHead, b.head())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Pushed brainfiles are stamped on top of the push
+		runGit(t, work, "pull", "--quiet", "--ff-only", b.config.GitDir, gitBranch)
+	}
+
+	// The first push to an empty brain, with a path git would quote
+	writeFiles(t, work, map[string]string{
+		"ops/ünïcode note.md":             "---\ntitle: Note\n---\n",
+		"ops/ünïcode note.assets/img.png": "png",
+	})
+	push("Add note")
+
+	for _, path := range []string{"ops/ünïcode note.md", "ops/ünïcode note.assets/img.png"} {
+		_, err = os.Stat(filepath.Join(b.config.ContentDir, path))
+		if err != nil {
+			t.Errorf("expected %s to be checked out: %s", path, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(work, "ops", "ünïcode note.md"))
+	if err != nil || !strings.Contains(string(data), "created_by:") || !strings.Contains(string(data), "updated_by:") {
+		t.Errorf("expected the pushed brainfile to be stamped got %q %v", data, err)
+	}
+
+	status, err := b.git("status", "--porcelain")
+	if err != nil || status != "" {
+		t.Errorf("expected the stamps to be committed got %q %v", status, err)
+	}
+
+	runGit(t, work, "mv", "ops/ünïcode note.md", "ops/renamed.md")
+	push("Rename note")
+
+	_, err = os.Stat(filepath.Join(b.config.ContentDir, "ops", "renamed.md"))
+	if err != nil {
+		t.Errorf("expected renamed.md to be checked out: %s", err)
+	}
+
+	_, err = os.Stat(filepath.Join(b.config.ContentDir, "ops", "ünïcode note.md"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected the old path to be removed got %v", err)
+	}
+
+	runGit(t, work, "rm", "--quiet", "ops/ünïcode note.assets/img.png")
+	push("Remove attachment")
+
+	// A push that can't be checked out is undone, here as it would
+	// overwrite a file not yet committed
+	writeFiles(t, work, map[string]string{"blocked.md": "---\ntitle: Pushed\n---\n"})
+	writeFiles(t, b.config.ContentDir, map[string]string{"blocked.md": "---\ntitle: Local\n---\n"})
+	runGit(t, work, "add", "--all")
+	runGit(t, work, "commit", "--quiet", "--message", "Add blocked")
+	oldHead := b.head()
+	runGit(t, work, "push", "--quiet", b.config.GitDir, gitBranch)
+	err = b.applyPush(newTestSession(""), oldHead, b.head())
+	if err == nil {
+		t.Error("expected checking out the push to fail")
+	}
+
+	if b.head() != oldHead {
+		t.Errorf("expected the branch to be reset to %s got %s", oldHead, b.head())
+	}
+
+	var actions []string
+	for _, entry := range entries() {
+		actions = append(actions, entry.Action+" "+entry.Path)
+	}
+
+	expected := []string{
+		ATTACH + " ops/ünïcode note.assets/img.png",
+		NEW + " ops/ünïcode note.md",
+		MOVE + " ops/renamed.md",
+		DELETE + " ops/ünïcode note.assets/img.png",
+	}
+	if !slices.Equal(actions, expected) {
+		t.Errorf("expected pushed changes to be audited as %v got %v", expected, actions)
+	}
+}
+
+func TestValidatePush(t *testing.T) {
+	work := t.TempDir()
+	runGit(t, work, "init", "--quiet", "--initial-branch", gitBranch)
+	t.Setenv("GIT_DIR", filepath.Join(work, ".git"))
+	commit := func(files map[string]string) string {
+		t.Helper()
+		writeFiles(t, work, files)
+		runGit(t, work, "add", "--all")
+		runGit(t, work, "commit", "--quiet", "--message", "commit")
+		return runGit(t, work, "rev-parse", "HEAD")
+	}
+
+	valid := commit(map[string]string{
+		"ünïcode note.md":             "---\ntitle: Note\n---\n",
+		"ünïcode note.assets/img.png": "png",
+	})
+	notAttachment := commit(map[string]string{"notes.txt": "text"})
+	runGit(t, work, "rm", "--quiet", "notes.txt")
+	inAssets := commit(map[string]string{"ünïcode note.assets/other.md": "---\ntitle: Other\n---\n"})
+
+	tests := []struct {
+		input    string
+		expected error
+	}{
+		{gitZeroHash + " " + valid + " " + gitRef, nil},
+		{valid + " " + notAttachment + " " + gitRef, ErrNotAttachment},
+		{notAttachment + " " + inAssets + " " + gitRef, ErrInAssetsDir},
+	}
+
+	for _, test := range tests {
+		err := ValidatePush(strings.NewReader(test.input), nil)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v got %v", test.input, test.expected, err)
+		}
+	}
+
+	err := ValidatePush(strings.NewReader(gitZeroHash+" "+valid+" refs/heads/other"), nil)
+	if err == nil {
+		t.Error("expected pushing another branch to fail")
+	}
+}
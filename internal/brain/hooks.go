@@ -0,0 +1,170 @@
+package brain
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/anmitsu/go-shlex"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/jedrw/brain/internal/config"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+type EventType string
+
+const (
+	NodeCreated EventType = "created"
+	NodeUpdated EventType = "updated"
+	NodeMoved   EventType = "moved"
+	NodeDeleted EventType = "deleted"
+
+	SignatureHeader = "X-Brain-Signature-256"
+	EventHeader     = "X-Brain-Event"
+)
+
+var (
+	hookBackoff = time.Second
+	hookClient  = &http.Client{Timeout: 10 * time.Second}
+)
+
+type Event struct {
+	Type    EventType `json:"type"`
+	Brain   string    `json:"brain,omitempty"`
+	Path    string    `json:"path"`
+	OldPath string    `json:"oldPath,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+}
+
+func newEvent(eventType EventType, s ssh.Session, node *Node, path string) Event {
+	event := Event{
+		Type:   eventType,
+		Path:   path,
+		Author: fingerprint(s.PublicKey()),
+		Time:   time.Now().UTC(),
+	}
+
+	if node != nil {
+		event.Title = node.Title
+		event.Tags = node.Tags
+	}
+
+	return event
+}
+
+func fingerprint(key ssh.PublicKey) string {
+	if key == nil {
+		return ""
+	}
+
+	return gossh.FingerprintSHA256(key)
+}
+
+// sign returns the hex encoded HMAC-SHA256 of payload using secret.
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (b *Brain) emit(event Event) {
+	event.Brain = b.name
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Warnf("failed to marshal %s event: %s", event.Type, err)
+		return
+	}
+
+	for _, hook := range b.config.Hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, string(event.Type)) {
+			continue
+		}
+
+		go b.deliver(hook, event, payload)
+	}
+}
+
+func (b *Brain) deliver(hook config.Hook, event Event, payload []byte) {
+	var err error
+	backoff := hookBackoff
+	for attempt := 0; attempt <= hook.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if hook.Command != "" {
+			err = runHookCommand(hook, event, payload)
+		} else {
+			err = postHook(hook, event, payload)
+		}
+
+		if err == nil {
+			log.Info("delivered hook", "event", event.Type, "path", event.Path)
+			return
+		}
+
+		log.Warn("failed to deliver hook", "event", event.Type, "path", event.Path, "attempt", attempt+1, "err", err)
+	}
+}
+
+func runHookCommand(hook config.Hook, event Event, payload []byte) error {
+	args, err := shlex.Split(hook.Command, true)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("empty hook command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(cmd.Environ(), fmt.Sprintf("BRAIN_EVENT=%s", event.Type))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, string(output))
+	}
+
+	return nil
+}
+
+func postHook(hook config.Hook, event Event, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event.Type))
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+sign(hook.Secret, payload))
+	}
+
+	resp, err := hookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
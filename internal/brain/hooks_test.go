@@ -0,0 +1,127 @@
+package brain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jedrw/brain/internal/config"
+)
+
+func fastBackoff(t *testing.T) {
+	backoff := hookBackoff
+	hookBackoff = time.Millisecond
+	t.Cleanup(func() { hookBackoff = backoff })
+}
+
+func TestDeliverHTTP(t *testing.T) {
+	fastBackoff(t)
+	secret := "s3cret"
+	received := make(chan Event, 1)
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if r.Header.Get(SignatureHeader) != "sha256="+sign(secret, body) {
+			t.Errorf("unexpected signature: %s", r.Header.Get(SignatureHeader))
+		}
+
+		if r.Header.Get(EventHeader) != string(NodeMoved) {
+			t.Errorf("unexpected event header: %s", r.Header.Get(EventHeader))
+		}
+
+		var event Event
+		err = json.Unmarshal(body, &event)
+		if err != nil {
+			t.Error(err)
+		}
+
+		received <- event
+	}))
+	defer server.Close()
+
+	b := &Brain{
+		ctx: context.Background(),
+		config: config.Config{Server: config.Server{
+			Hooks: []config.Hook{{URL: server.URL, Secret: secret, Retries: 1}},
+		}},
+	}
+
+	b.emit(Event{Type: NodeMoved, Path: "new.md", OldPath: "old.md", Title: "Test"})
+
+	select {
+	case event := <-received:
+		if event.Path != "new.md" || event.OldPath != "old.md" || event.Title != "Test" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook was not delivered")
+	}
+}
+
+func TestDeliverCommand(t *testing.T) {
+	fastBackoff(t)
+	out := filepath.Join(t.TempDir(), "event")
+	marker := filepath.Join(t.TempDir(), "failed")
+	// Fails the first attempt to check it's retried
+	command := `sh -c 'test -e "$0" || { touch "$0"; exit 1; }; { echo "$BRAIN_EVENT"; cat; } > "$1"' ` + marker + " " + out
+	b := &Brain{ctx: context.Background()}
+	event := Event{Type: NodeCreated, Path: "new.md", Title: "Test"}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.deliver(config.Hook{Command: command, Retries: 1}, event, payload)
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := string(NodeCreated) + "\n" + string(payload)
+	if string(data) != expected {
+		t.Errorf("expected %q got %q", expected, data)
+	}
+}
+
+func TestEmitFiltersEvents(t *testing.T) {
+	received := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(EventHeader)
+	}))
+	defer server.Close()
+
+	b := &Brain{
+		ctx: context.Background(),
+		config: config.Config{Server: config.Server{
+			Hooks: []config.Hook{{URL: server.URL, Events: []string{string(NodeDeleted)}}},
+		}},
+	}
+
+	b.emit(Event{Type: NodeCreated, Path: "new.md"})
+	b.emit(Event{Type: NodeDeleted, Path: "new.md"})
+
+	select {
+	case eventType := <-received:
+		if eventType != string(NodeDeleted) {
+			t.Errorf("expected only deleted events got %s", eventType)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hook was not delivered")
+	}
+}
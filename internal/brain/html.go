@@ -0,0 +1,171 @@
+package brain
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const ExportHTML = "html"
+
+var (
+	hrefRegexp = regexp.MustCompile(`href="([^"]*)"`)
+	idRegexp   = regexp.MustCompile(`(\s)id="([^"]*)"`)
+)
+
+var htmlTemplate = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; line-height: 1.5; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+pre { background: #f5f5f5; padding: 0.75rem; overflow-x: auto; }
+code { font-family: ui-monospace, monospace; font-size: 0.9em; }
+img { max-width: 100%; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.25rem 0.5rem; }
+nav ul { list-style: none; padding-left: 1.25rem; }
+nav > ul { padding-left: 0; }
+.path { color: #777; font-size: 0.85em; }
+article { border-top: 1px solid #ddd; margin-top: 2rem; }
+@media print {
+	body { max-width: none; margin: 0; }
+	nav { page-break-after: always; }
+	article { border: none; margin: 0; page-break-before: always; }
+	pre { white-space: pre-wrap; }
+	a { color: inherit; text-decoration: none; }
+}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<nav>
+<h2>Contents</h2>
+{{template "toc" .TOC}}
+</nav>
+{{range .Sections}}<article id="{{.ID}}">
+<h1>{{.Title}}</h1>
+<p class="path">{{.Path}}</p>
+{{.Content}}
+</article>
+{{end}}</body>
+</html>
+{{define "toc"}}<ul>
+{{range .}}<li>{{if .Section}}<a href="#{{.Section.ID}}">{{.Section.Title}}</a>{{else}}{{.Name}}/{{template "toc" .Children}}{{end}}</li>
+{{end}}</ul>{{end}}
+`))
+
+type htmlSection struct {
+	ID      string
+	Title   string
+	Path    string
+	Content template.HTML
+}
+
+type tocItem struct {
+	Name     string
+	Section  *htmlSection
+	Children []*tocItem
+}
+
+// anchor converts a brainfile path into an element ID.
+func anchor(relPath string) string {
+	var sb strings.Builder
+	sb.WriteString("brainfile-")
+	for _, r := range strings.ToLower(strings.TrimSuffix(relPath, ".md")) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('-')
+		}
+	}
+
+	return sb.String()
+}
+
+// rewriteIDs prefixes element IDs, such as those of headings, with the
+// anchor of their section so they are unique within the document.
+func rewriteIDs(content []byte, sectionID string) []byte {
+	return idRegexp.ReplaceAll(content, []byte(`${1}id="`+sectionID+`-${2}"`))
+}
+
+// rewriteLinks points links between exported brainfiles, and to headings
+// within them, at their section in the document, leaving other links
+// untouched.
+func rewriteLinks(content []byte, relPath string, ids map[string]string) []byte {
+	return hrefRegexp.ReplaceAllFunc(content, func(match []byte) []byte {
+		href := string(hrefRegexp.FindSubmatch(match)[1])
+		link, err := url.Parse(href)
+		if err != nil || link.Scheme != "" || link.Host != "" || (link.Path == "" && link.Fragment == "") {
+			return match
+		}
+
+		target := relPath
+		if link.Path != "" {
+			target = path.Join(path.Dir(relPath), link.Path)
+		}
+
+		id, ok := ids[target]
+		if !ok {
+			return match
+		}
+
+		if link.Fragment != "" {
+			id += "-" + link.Fragment
+		}
+
+		return fmt.Appendf(nil, `href="#%s"`, template.HTMLEscapeString(id))
+	})
+}
+
+// writeHTML writes nodes as a single self-contained HTML document with a
+// table of contents, suitable for printing. Images in contentDir are inlined.
+func writeHTML(w io.Writer, title, root, contentDir string, nodes []*Node) error {
+	ids := map[string]string{}
+	for _, node := range nodes {
+		ids[node.Path] = anchor(node.Path)
+	}
+
+	var toc []*tocItem
+	dirs := map[string]*tocItem{}
+	var sections []*htmlSection
+	for _, node := range nodes {
+		section := &htmlSection{
+			ID:      ids[node.Path],
+			Title:   node.Title,
+			Path:    node.Path,
+			Content: template.HTML(inlineImages(rewriteIDs(rewriteLinks(node.Content, node.Path, ids), ids[node.Path]), node.Path, contentDir)),
+		}
+		sections = append(sections, section)
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(node.Path, root), "/")
+		parts := strings.Split(rel, "/")
+		level := &toc
+		for i, part := range parts[:len(parts)-1] {
+			dirPath := strings.Join(parts[:i+1], "/")
+			dir, ok := dirs[dirPath]
+			if !ok {
+				dir = &tocItem{Name: part}
+				dirs[dirPath] = dir
+				*level = append(*level, dir)
+			}
+
+			level = &dir.Children
+		}
+
+		*level = append(*level, &tocItem{Section: section})
+	}
+
+	return htmlTemplate.Execute(w, map[string]any{
+		"Title":    title,
+		"TOC":      toc,
+		"Sections": sections,
+	})
+}
@@ -0,0 +1,53 @@
+package brain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	nodes := []*Node{
+		{Title: "Start", Path: "onboarding/start.md", Content: []byte(`<h2 id="ssh">SSH</h2><p><a href="setup/laptop.md#ssh">laptop</a> <a href="#ssh">above</a> <a href="../ops/deploy.md">deploy</a> <a href="https://example.com/x.md">web</a></p>`)},
+		{Title: "Laptop <setup>", Path: "onboarding/setup/laptop.md", Content: []byte(`<h2 id="ssh">SSH</h2><p><a href="../start.md">back</a><img src="laptop.assets/dock.png" alt="dock"></p>`)},
+	}
+
+	contentDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(contentDir, "onboarding/setup/laptop.assets"), 0770)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(contentDir, "onboarding/setup/laptop.assets/dock.png"), []byte("png"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	err = writeHTML(&sb, "Onboarding", "onboarding", contentDir, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`<title>Onboarding</title>`,
+		`<li><a href="#brainfile-onboarding-start">Start</a></li>`,
+		`<li>setup/<ul>`,
+		`<article id="brainfile-onboarding-setup-laptop">`,
+		`<h1>Laptop &lt;setup&gt;</h1>`,
+		`<h2 id="brainfile-onboarding-start-ssh">SSH</h2>`,
+		`<h2 id="brainfile-onboarding-setup-laptop-ssh">SSH</h2>`,
+		`<a href="#brainfile-onboarding-setup-laptop-ssh">laptop</a>`,
+		`<a href="#brainfile-onboarding-start-ssh">above</a>`,
+		`<a href="../ops/deploy.md">deploy</a>`,
+		`<a href="https://example.com/x.md">web</a>`,
+		`<a href="#brainfile-onboarding-start">back</a>`,
+		`<img src="data:image/png;base64,cG5n" alt="dock">`,
+		`@media print`,
+	} {
+		if !strings.Contains(sb.String(), expected) {
+			t.Errorf("expected document to contain %q got:\n%s", expected, sb.String())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package brain
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+)
+
+type ManifestEntry struct {
+	Path        string    `json:"path"`
+	Title       string    `json:"title"`
+	Tags        []string  `json:"tags,omitempty"`
+	Hash        string    `json:"hash"`
+	ModTime     time.Time `json:"modTime"`
+	Attachments []string  `json:"attachments,omitempty"`
+	// AttachmentHashes maps the path of each attachment to its hash.
+	AttachmentHashes map[string]string `json:"attachmentHashes,omitempty"`
+}
+
+// files returns every brainfile under root, or the whole tree if root is empty.
+func (t *Tree) files(root string) []*Node {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var files []*Node
+	var walk func(nodes []*Node)
+	walk = func(nodes []*Node) {
+		for _, node := range nodes {
+			if node.IsDir {
+				walk(node.Children)
+				continue
+			}
+
+			if root != "" && !matchesPath(node.Path, root) {
+				continue
+			}
+
+			files = append(files, node)
+		}
+	}
+	walk(t.nodes)
+
+	return files
+}
+
+func newManifestEntry(node *Node) ManifestEntry {
+	return ManifestEntry{
+		Path:        node.Path,
+		Title:       node.Title,
+		Tags:        node.Tags,
+		Hash:        hashContent(node.Raw),
+		ModTime:     node.ModTime,
+		Attachments: node.Attachments,
+	}
+}
+
+// Manifest describes every brainfile under root, or the whole tree if root
+// is empty.
+func (t *Tree) Manifest(root string) []ManifestEntry {
+	entries := []ManifestEntry{}
+	for _, node := range t.files(root) {
+		entries = append(entries, newManifestEntry(node))
+	}
+
+	return entries
+}
+
+func (b *Brain) handleManifest(s ssh.Session) error {
+	root := ""
+	if len(s.Command()) > 1 && filepath.Clean(s.Command()[1]) != "." {
+		root = filepath.Clean(s.Command()[1])
+	}
+
+	entries := b.tree.Manifest(root)
+	for i, entry := range entries {
+		for _, attachment := range entry.Attachments {
+			if entries[i].AttachmentHashes == nil {
+				entries[i].AttachmentHashes = map[string]string{}
+			}
+
+			entries[i].AttachmentHashes[attachment] = hashFile(filepath.Join(b.config.ContentDir, attachment))
+		}
+	}
+
+	log.Info("sent manifest")
+	return json.NewEncoder(s).Encode(entries)
+}
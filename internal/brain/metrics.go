@@ -0,0 +1,65 @@
+package brain
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	commandsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "brain_ssh_commands_total",
+		Help: "SSH commands handled by command and result.",
+	}, []string{"command", "result"})
+
+	sessionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "brain_ssh_sessions_total",
+		Help: "SSH sessions opened.",
+	})
+
+	sessionsActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "brain_ssh_sessions_active",
+		Help: "SSH sessions currently open.",
+	})
+
+	treeNodes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "brain_tree_nodes",
+		Help: "Brainfiles in the tree by brain.",
+	}, []string{"brain"})
+
+	treeParseFailures = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "brain_tree_parse_failures",
+		Help: "Files that failed to parse as brainfiles in the last tree update by brain.",
+	}, []string{"brain"})
+
+	treeUpdateFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "brain_tree_update_failures_total",
+		Help: "Tree updates that failed to read the content dir by brain.",
+	}, []string{"brain"})
+
+	updateTaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "brain_update_task_duration_seconds",
+		Help:    "Duration of update tasks by task and result.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+	}, []string{"task", "result"})
+)
+
+func countNodes(nodes []*Node) int {
+	count := 0
+	for _, node := range nodes {
+		if node.IsDir {
+			count += countNodes(node.Children)
+		} else {
+			count++
+		}
+	}
+
+	return count
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "ok"
+}
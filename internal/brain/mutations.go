@@ -0,0 +1,289 @@
+package brain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+)
+
+var ErrInvalidPath = errors.New("path must be within the brain")
+
+// contentPath resolves relPath within the content dir, rejecting paths that
+// would escape it.
+func (b *Brain) contentPath(relPath string) (string, error) {
+	if !filepath.IsLocal(relPath) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
+	}
+
+	return filepath.Join(b.config.ContentDir, relPath), nil
+}
+
+// saveNode validates data as a brainfile and writes it to relPath, creating
+// or replacing the node, recording when and by whom in its frontmatter.
+func (b *Brain) saveNode(s ssh.Session, relPath string, data []byte) error {
+	return b.storeNode(s, relPath, data, true)
+}
+
+// restoreNode is saveNode for data that already records its history, such as
+// from an archive.
+func (b *Brain) restoreNode(s ssh.Session, relPath string, data []byte) error {
+	return b.storeNode(s, relPath, data, false)
+}
+
+// updateNode saves the result of applying update to the brainfile at relPath
+// as it is on disk rather than in the tree, which may not have caught up
+// with recent writes.
+func (b *Brain) updateNode(s ssh.Session, relPath string, update func([]byte) ([]byte, error)) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	path, err := b.contentPath(relPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return ErrNotExist
+	} else if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return ErrNodeIsDir
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := update(current)
+	if err != nil {
+		return err
+	}
+
+	return b.writeNode(s, relPath, data, true)
+}
+
+func (b *Brain) storeNode(s ssh.Session, relPath string, data []byte, stamped bool) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	return b.writeNode(s, relPath, data, stamped)
+}
+
+func (b *Brain) writeNode(s ssh.Session, relPath string, data []byte, stamped bool) (err error) {
+	entry := newAuditEntry(s, NEW)
+	entry.Path = relPath
+	defer func() { b.audit(entry, err) }()
+
+	if !strings.HasSuffix(relPath, ".md") {
+		return errors.New("brainfile path must end with \".md\"")
+	}
+
+	if InAssetsDir(relPath) {
+		return ErrInAssetsDir
+	}
+
+	// Validate the data
+	node, err := NewNodeFromBytes(data)
+	if err != nil {
+		return err
+	}
+
+	newFilePath, err := b.contentPath(relPath)
+	if err != nil {
+		return err
+	}
+
+	entry.HashBefore = hashFile(newFilePath)
+	eventType := NodeUpdated
+	existing, err := os.ReadFile(newFilePath)
+	if os.IsNotExist(err) {
+		eventType = NodeCreated
+	} else if err != nil {
+		return err
+	}
+
+	if stamped {
+		data, err = stamp(data, existing, fingerprint(s.PublicKey()), time.Now())
+		if err != nil {
+			return err
+		}
+
+		node, err = NewNodeFromBytes(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = b.schema.Validate(relPath, node)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(newFilePath), 0770)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(newFilePath, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	entry.HashAfter = hashContent(data)
+	b.update()
+	b.emit(newEvent(eventType, s, &node, relPath))
+
+	return nil
+}
+
+// moveNode non-destructively moves the node at fromPathRel to toPathRel.
+func (b *Brain) moveNode(s ssh.Session, fromPathRel, toPathRel string) (err error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	entry := newAuditEntry(s, MOVE)
+	entry.OldPath = fromPathRel
+	entry.Path = toPathRel
+	defer func() { b.audit(entry, err) }()
+
+	if fromPathRel == toPathRel {
+		return nil
+	}
+
+	if InAssetsDir(toPathRel) {
+		return ErrInAssetsDir
+	}
+
+	fromPath, err := b.contentPath(fromPathRel)
+	if err != nil {
+		return err
+	}
+
+	toPath, err := b.contentPath(toPathRel)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(toPath)
+	if err == nil {
+		b.update()
+		return fmt.Errorf("%s already exists, move must be non-destructive", toPathRel)
+	}
+
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	raw, err := os.ReadFile(fromPath)
+	if os.IsNotExist(err) {
+		b.update()
+		return ErrNotExist
+	} else if err != nil {
+		return err
+	}
+
+	fromNode, err := NewNodeFromBytes(raw)
+	if err != nil {
+		return err
+	}
+
+	err = b.schema.Validate(toPathRel, fromNode)
+	if err != nil {
+		return err
+	}
+
+	entry.HashBefore = hashContent(raw)
+	err = os.MkdirAll(filepath.Dir(toPath), 0770)
+	if err != nil {
+		return err
+	}
+
+	data, hasAttachments, err := b.relinkAttachments(fromPathRel, toPathRel, raw)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(toPath, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	if hasAttachments {
+		// Only moved once the brainfile has been written, which is undone if
+		// they can't be
+		err = b.moveAttachments(fromPathRel, toPathRel)
+		if err != nil {
+			os.Remove(toPath)
+			removeEmptyDirs(b.config.ContentDir, filepath.Dir(toPathRel))
+			return err
+		}
+	}
+
+	entry.HashAfter = hashFile(toPath)
+
+	err = os.Remove(fromPath)
+	if err != nil {
+		return err
+	}
+
+	fromDirRel := filepath.Dir(fromPathRel)
+	err = removeEmptyDirs(b.config.ContentDir, fromDirRel)
+	if err != nil {
+		return err
+	}
+
+	b.update()
+	event := newEvent(NodeMoved, s, &fromNode, toPathRel)
+	event.OldPath = fromPathRel
+	b.emit(event)
+
+	return nil
+}
+
+// deleteNode removes the node at relPath and its attachments along with any
+// directories left empty.
+func (b *Brain) deleteNode(s ssh.Session, relPath string) (err error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	entry := newAuditEntry(s, DELETE)
+	entry.Path = relPath
+	defer func() { b.audit(entry, err) }()
+
+	path, err := b.contentPath(relPath)
+	if err != nil {
+		return err
+	}
+
+	entry.HashBefore = hashFile(path)
+	node, _ := b.tree.Find(relPath)
+	err = os.Remove(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(filepath.Join(b.config.ContentDir, AssetsDir(relPath)))
+	if err != nil {
+		return err
+	}
+
+	fromDirRel := filepath.Dir(relPath)
+	err = removeEmptyDirs(b.config.ContentDir, fromDirRel)
+	if err != nil {
+		return err
+	}
+
+	b.update()
+	b.emit(newEvent(NodeDeleted, s, node, relPath))
+
+	return nil
+}
@@ -5,7 +5,10 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/log"
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/parser"
 )
@@ -13,14 +16,28 @@ import (
 //go:embed template/brainfile.md
 var BrainfileTemplate []byte
 
+//go:embed template/daily.md
+var dailyTemplate []byte
+
+//go:embed template/weekly.md
+var weeklyTemplate []byte
+
 type Node struct {
-	Title    string
-	Tags     []string
-	Raw      []byte
-	Content  []byte
-	Path     string
-	IsDir    bool
-	Children []*Node
+	Title       string
+	Tags        []string
+	Meta        map[string]any
+	Raw         []byte
+	Content     []byte
+	Path        string
+	ModTime     time.Time
+	Created     time.Time
+	Updated     time.Time
+	CreatedBy   string
+	UpdatedBy   string
+	Attachments []string
+	Tasks       []Task
+	IsDir       bool
+	Children    []*Node
 }
 
 func NewNodeFromBytes(data []byte) (Node, error) {
@@ -38,10 +55,12 @@ func NewNodeFromBytes(data []byte) (Node, error) {
 	node := Node{
 		Raw:     data,
 		Content: htmlBytes.Bytes(),
+		Tasks:   parseTasks(data),
 		IsDir:   false,
 	}
 
 	metadata := meta.Get(context)
+	node.Meta = metadata
 	v, ok := metadata["title"]
 	if !ok {
 		return node, fmt.Errorf("%w: brainfile must contain \"title\" frontmatter", ErrInvalidBrainNode)
@@ -56,9 +75,58 @@ func NewNodeFromBytes(data []byte) (Node, error) {
 		return node, fmt.Errorf("%w: brainfile \"title\" must not be an empty string", ErrInvalidBrainNode)
 	}
 
+	node.Tags = parseTags(metadata["tags"])
+
+	node.Created = parseMetaTime(metadata[CreatedField])
+	node.Updated = parseMetaTime(metadata[UpdatedField])
+	node.CreatedBy, _ = metadata[CreatedByField].(string)
+	node.UpdatedBy, _ = metadata[UpdatedByField].(string)
+
 	return node, nil
 }
 
+// parseTags reads the tags frontmatter as a list or a comma or space
+// separated string. Brainfiles are not rejected for other values, which are
+// used as tags where they're scalars and otherwise ignored.
+func parseTags(v any) []string {
+	switch tags := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return strings.Fields(strings.ReplaceAll(tags, ",", " "))
+	case []any:
+		var parsed []string
+		for _, tag := range tags {
+			s, ok := scalarTag(tag)
+			if !ok {
+				log.Warn("ignoring brainfile tag that is not a string", "tag", tag)
+				continue
+			}
+
+			parsed = append(parsed, s)
+		}
+
+		return parsed
+	default:
+		s, ok := scalarTag(tags)
+		if !ok {
+			log.Warn("ignoring brainfile tags that are not a list", "tags", tags)
+			return nil
+		}
+
+		return []string{s}
+	}
+}
+
+func scalarTag(v any) (string, bool) {
+	switch v.(type) {
+	case string, int, int64, uint64, float64, bool:
+		return fmt.Sprint(v), true
+	}
+
+	return "", false
+}
+
 func NewNodeFromFile(filePath string) (Node, error) {
 	fileBytes, err := os.ReadFile(filePath)
 	if err != nil {
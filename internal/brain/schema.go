@@ -0,0 +1,217 @@
+package brain
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jedrw/brain/internal/config"
+)
+
+const (
+	FieldString = "string"
+	FieldNumber = "number"
+	FieldBool   = "bool"
+	FieldDate   = "date"
+	FieldList   = "list"
+
+	// SchemaEnv passes the schema to git hooks.
+	SchemaEnv = "BRAIN_SCHEMA"
+)
+
+var (
+	fieldTypes = []string{FieldString, FieldNumber, FieldBool, FieldDate, FieldList}
+
+	// standardFields are validated wherever they are used once a schema is
+	// configured.
+	standardFields = map[string]config.Field{
+		CreatedField:   {Type: FieldDate},
+		UpdatedField:   {Type: FieldDate},
+		CreatedByField: {Type: FieldString},
+		UpdatedByField: {Type: FieldString},
+		"author":       {Type: FieldString},
+		"status":       {Type: FieldString},
+		"aliases":      {Type: FieldList},
+	}
+
+	// dateLayouts are accepted for date fields without a format.
+	dateLayouts = []string{time.DateOnly, time.RFC3339, time.DateTime}
+)
+
+// Schema is the frontmatter brainfiles must have, declared in the server config.
+type Schema []config.Schema
+
+// SchemaFromEnv returns the schema passed to a git hook.
+func SchemaFromEnv(value string) (Schema, error) {
+	var schema Schema
+	if value == "" {
+		return schema, nil
+	}
+
+	err := json.Unmarshal([]byte(value), &schema)
+	return schema, err
+}
+
+func (s Schema) env() string {
+	data, _ := json.Marshal(s)
+	return SchemaEnv + "=" + string(data)
+}
+
+// Check returns an error if the schema itself is invalid.
+func (s Schema) Check() error {
+	for _, rule := range s {
+		for name, field := range rule.Fields {
+			if !slices.Contains(fieldTypes, field.Type) {
+				return fmt.Errorf("schema field %q has unknown type %q, use one of %s", name, field.Type, strings.Join(fieldTypes, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesSchemaPath reports whether relPath is within the directory pattern
+// or matches it as a glob.
+func matchesSchemaPath(pattern, relPath string) bool {
+	pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "**"), "/")
+	if pattern == "" || matchesPath(relPath, pattern) {
+		return true
+	}
+
+	matched, _ := path.Match(pattern, relPath)
+	return matched
+}
+
+// fields returns the fields that apply to relPath, with later rules
+// overriding earlier ones and the standard fields. Without a schema no fields
+// apply, so brainfiles from other tools are accepted as they are.
+func (s Schema) fields(relPath string) map[string]config.Field {
+	fields := map[string]config.Field{}
+	if len(s) == 0 {
+		return fields
+	}
+
+	for name, field := range standardFields {
+		fields[name] = field
+	}
+
+	for _, rule := range s {
+		matches := len(rule.Paths) == 0
+		for _, pattern := range rule.Paths {
+			matches = matches || matchesSchemaPath(pattern, relPath)
+		}
+
+		if !matches {
+			continue
+		}
+
+		for name, field := range rule.Fields {
+			fields[name] = field
+		}
+	}
+
+	return fields
+}
+
+func isDate(value any, format string) bool {
+	if _, ok := value.(time.Time); ok {
+		return true
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	layouts := dateLayouts
+	if format != "" {
+		layouts = []string{format}
+	}
+
+	for _, layout := range layouts {
+		_, err := time.Parse(layout, s)
+		if err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateField(name string, field config.Field, value any, set bool) string {
+	if !set || value == nil {
+		if field.Required {
+			return fmt.Sprintf("%q is required", name)
+		}
+
+		return ""
+	}
+
+	valid := true
+	description := "a " + field.Type
+	switch field.Type {
+	case FieldString:
+		_, valid = value.(string)
+	case FieldNumber:
+		switch value.(type) {
+		case int, int64, uint64, float64:
+		default:
+			valid = false
+		}
+	case FieldBool:
+		_, valid = value.(bool)
+	case FieldDate:
+		valid = isDate(value, field.Format)
+		if field.Format != "" {
+			description = "a date formatted " + field.Format
+		}
+	case FieldList:
+		var items []any
+		items, valid = value.([]any)
+		for _, item := range items {
+			_, ok := item.(string)
+			valid = valid && ok
+		}
+
+		description = "a list of strings"
+	}
+
+	if !valid {
+		return fmt.Sprintf("%q must be %s", name, description)
+	}
+
+	if len(field.Enum) > 0 && !slices.Contains(field.Enum, fmt.Sprint(value)) {
+		return fmt.Sprintf("%q must be one of %s", name, strings.Join(field.Enum, ", "))
+	}
+
+	return ""
+}
+
+// Validate returns an error describing every way the frontmatter of the
+// brainfile at relPath does not match the schema.
+func (s Schema) Validate(relPath string, node Node) error {
+	fields := s.fields(relPath)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var problems []string
+	for _, name := range names {
+		value, set := node.Meta[name]
+		problem := validateField(name, fields[name], value, set)
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidBrainNode, relPath, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
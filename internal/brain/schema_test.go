@@ -0,0 +1,89 @@
+package brain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jedrw/brain/internal/config"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := Schema{
+		{
+			Paths: []string{"runbooks/**"},
+			Fields: map[string]config.Field{
+				"owner":    {Type: FieldString, Required: true},
+				"reviewed": {Type: FieldDate, Required: true, Format: "2006-01-02"},
+			},
+		},
+		{
+			Fields: map[string]config.Field{
+				"status": {Type: FieldString, Enum: []string{"draft", "published"}},
+			},
+		},
+	}
+
+	err := schema.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		data     string
+		problems []string
+	}{
+		{"notes.md", "---\ntitle: Notes\n---\n", nil},
+		{"notes.md", "---\ntitle: Notes\nstatus: done\ncreated: yesterday\naliases: [a, [b]]\n---\n", []string{
+			`"aliases" must be a list of strings`,
+			`"created" must be a date`,
+			`"status" must be one of draft, published`,
+		}},
+		{"runbooks/deploy.md", "---\ntitle: Deploy\n---\n", []string{`"owner" is required`, `"reviewed" is required`}},
+		{"runbooks/deploy.md", "---\ntitle: Deploy\nowner: ops\nreviewed: 01/10/2026\n---\n", []string{`"reviewed" must be a date formatted 2006-01-02`}},
+		{"runbooks/deploy.md", "---\ntitle: Deploy\nowner: ops\nreviewed: 2026-10-01\nstatus: draft\ncreated: 2026-10-01T08:00:00Z\n---\n", nil},
+	}
+
+	for _, test := range tests {
+		node, err := NewNodeFromBytes([]byte(test.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = schema.Validate(test.path, node)
+		if len(test.problems) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", test.path, err)
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, ErrInvalidBrainNode) {
+			t.Fatalf("%s: expected invalid brain node error got %v", test.path, err)
+		}
+
+		for _, problem := range test.problems {
+			if !strings.Contains(err.Error(), problem) {
+				t.Errorf("%s: expected %q in %q", test.path, problem, err)
+			}
+		}
+	}
+
+	// Without a schema the standard fields aren't enforced
+	node, err := NewNodeFromBytes([]byte("---\ntitle: Plan\naliases: plan\ncreated: yesterday\n---\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Schema(nil).Validate("plan.md", node)
+	if err != nil {
+		t.Errorf("expected no schema to accept any frontmatter got %s", err)
+	}
+
+	err = Schema{{Fields: map[string]config.Field{"owner": {Type: "person"}}}}.Check()
+	if err == nil {
+		t.Error("expected error for unknown field type")
+	}
+}
@@ -0,0 +1,122 @@
+package brain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var ErrSectionNotExist = errors.New("section does not exist")
+
+// SplitAnchor splits a path addressing a section of a brainfile, such as
+// runbook.md#deploy-steps, into the brainfile path and heading anchor.
+func SplitAnchor(path string) (string, string) {
+	i := strings.Index(path, ".md#")
+	if i == -1 {
+		return path, ""
+	}
+
+	return path[:i+len(".md")], path[i+len(".md#"):]
+}
+
+// HeadingAnchor returns the anchor of a heading with the given text, as
+// generated when a brainfile is rendered. Repeated headings are given a
+// numbered suffix when rendered that isn't included.
+func HeadingAnchor(heading string) string {
+	return string(parser.NewContext().IDs().Generate([]byte(heading), ast.KindHeading))
+}
+
+func lineStart(data []byte, offset int) int {
+	return bytes.LastIndexByte(data[:offset], '\n') + 1
+}
+
+// findSection returns the byte range of the section of data under the
+// heading with the given anchor, up to the next heading of the same or a
+// higher level.
+func findSection(data []byte, anchor string) (int, int, error) {
+	doc := markdownParser.Parser().Parse(text.NewReader(data), parser.WithContext(parser.NewContext()))
+	start, end, level := -1, len(data), 0
+	for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
+		heading, ok := child.(*ast.Heading)
+		if !ok || heading.Lines().Len() == 0 {
+			continue
+		}
+
+		offset := lineStart(data, heading.Lines().At(0).Start)
+		if start != -1 {
+			if heading.Level <= level {
+				end = offset
+				break
+			}
+
+			continue
+		}
+
+		id, _ := heading.AttributeString("id")
+		if id, ok := id.([]byte); ok && string(id) == anchor {
+			start, level = offset, heading.Level
+		}
+	}
+
+	if start == -1 {
+		return 0, 0, fmt.Errorf("%w: #%s", ErrSectionNotExist, anchor)
+	}
+
+	return start, end, nil
+}
+
+// section returns the section of data under the heading with anchor.
+func section(data []byte, anchor string) ([]byte, error) {
+	start, end, err := findSection(data, anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	return data[start:end], nil
+}
+
+// replaceSection replaces the section of data under the heading with anchor.
+func replaceSection(data []byte, anchor string, replacement []byte) ([]byte, error) {
+	start, end, err := findSection(data, anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	if end < len(data) && !bytes.HasSuffix(replacement, []byte("\n")) {
+		replacement = append(replacement, '\n')
+	}
+
+	replaced := append([]byte{}, data[:start]...)
+	replaced = append(replaced, replacement...)
+	return append(replaced, data[end:]...), nil
+}
+
+// appendSection appends addition to the end of the section of data under the
+// heading with anchor, or to the end of data if anchor is empty, keeping any
+// blank lines before the next heading.
+func appendSection(data []byte, anchor string, addition []byte) ([]byte, error) {
+	end := len(data)
+	if anchor != "" {
+		var err error
+		_, end, err = findSection(data, anchor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	content := bytes.TrimRight(data[:end], "\n")
+	tail := data[len(content):]
+	if !bytes.HasPrefix(tail, []byte("\n")) {
+		tail = append([]byte("\n"), tail...)
+	}
+
+	appended := append([]byte{}, content...)
+	appended = append(appended, '\n')
+	appended = append(appended, bytes.TrimRight(addition, "\n")...)
+	return append(appended, tail...), nil
+}
@@ -0,0 +1,135 @@
+package brain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const runbook = `---
+title: Runbook
+---
+
+# Runbook
+
+## Deploy steps
+
+1. Build
+2. Ship
+
+### Rollback
+
+Revert it.
+
+## Deploy steps
+
+Again.
+`
+
+func TestSplitAnchor(t *testing.T) {
+	for path, expected := range map[string][2]string{
+		"ops/runbook.md#deploy-steps": {"ops/runbook.md", "deploy-steps"},
+		"ops/runbook.md":              {"ops/runbook.md", ""},
+		"ops/c#.md":                   {"ops/c#.md", ""},
+	} {
+		relPath, anchor := SplitAnchor(path)
+		if relPath != expected[0] || anchor != expected[1] {
+			t.Errorf("%s: expected %v got %s %s", path, expected, relPath, anchor)
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	for anchor, expected := range map[string]string{
+		"deploy-steps":   "## Deploy steps\n\n1. Build\n2. Ship\n\n### Rollback\n\nRevert it.\n\n",
+		"rollback":       "### Rollback\n\nRevert it.\n\n",
+		"deploy-steps-1": "## Deploy steps\n\nAgain.\n",
+	} {
+		data, err := section([]byte(runbook), anchor)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != expected {
+			t.Errorf("%s: expected:\n%q\ngot:\n%q", anchor, expected, data)
+		}
+	}
+
+	_, err := section([]byte(runbook), "missing")
+	if !errors.Is(err, ErrSectionNotExist) {
+		t.Errorf("expected section not to exist got %v", err)
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	data, err := replaceSection([]byte(runbook), "rollback", []byte("### Rollback\n\nRedeploy the previous tag."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `---
+title: Runbook
+---
+
+# Runbook
+
+## Deploy steps
+
+1. Build
+2. Ship
+
+### Rollback
+
+Redeploy the previous tag.
+## Deploy steps
+
+Again.
+`
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestAppendSection(t *testing.T) {
+	for anchor, expected := range map[string]string{
+		"":         runbook + "3. Celebrate\n",
+		"rollback": strings.Replace(runbook, "Revert it.\n", "Revert it.\n3. Celebrate\n", 1),
+	} {
+		data, err := appendSection([]byte(runbook), anchor, []byte("3. Celebrate\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(data) != expected {
+			t.Errorf("%q: expected:\n%s\ngot:\n%s", anchor, expected, data)
+		}
+	}
+
+	data, err := appendSection([]byte("---\ntitle: A\n---\nno newline"), "", []byte("entry"))
+	if err != nil || string(data) != "---\ntitle: A\n---\nno newline\nentry\n" {
+		t.Errorf("expected entry on a new line got %q %v", data, err)
+	}
+}
+
+func TestNewSectionSequential(t *testing.T) {
+	b := newTestBrain(t, map[string]string{"ops.md": "---\ntitle: Ops\n---\n\n## Deploy\n\nold deploy\n\n## Rollback\n\nold rollback\n"})
+
+	// Both sections are replaced before the tree is rebuilt
+	for _, section := range [][2]string{{"deploy", "## Deploy\n\nnew deploy\n\n"}, {"rollback", "## Rollback\n\nnew rollback\n"}} {
+		err := b.handleNew(newTestSession(section[1], NEW, "ops.md#"+section[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.config.ContentDir, "ops.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "new deploy") || !strings.Contains(string(data), "new rollback") {
+		t.Errorf("expected both sections to be replaced got:\n%s", data)
+	}
+}
@@ -9,30 +9,39 @@ import (
 	"github.com/charmbracelet/wish/logging"
 )
 
-func newServer(hostKeyPath string, authorizedKeys []string, sshHandler func(s ssh.Handler) ssh.Handler) (*ssh.Server, error) {
+func newServer(hostKeyPath string, authorizedKeys []string, subsystems map[string]ssh.SubsystemHandler, middleware ...wish.Middleware) (*ssh.Server, error) {
 	_, err := os.Stat(hostKeyPath)
 	if err != nil {
 		return nil, err
 	}
 
-	return wish.NewServer(
+	options := []ssh.Option{
 		wish.WithHostKeyPath(hostKeyPath),
 		wish.WithPublicKeyAuth(func(_ ssh.Context, key ssh.PublicKey) bool {
-			for _, pubkey := range authorizedKeys {
-				parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubkey))
-				if err != nil {
-					log.Warn("failed to parse authorized key", "key", pubkey, "err", err)
-				}
-				if ssh.KeysEqual(key, parsed) {
-					return true
-				}
-			}
-
-			return false
+			return authorized(authorizedKeys, key)
 		}),
 		wish.WithMiddleware(
-			sshHandler,
-			logging.Middleware(),
+			append(middleware, logging.Middleware())...,
 		),
-	)
+	}
+
+	for name, handler := range subsystems {
+		options = append(options, wish.WithSubsystem(name, handler))
+	}
+
+	return wish.NewServer(options...)
+}
+
+func authorized(authorizedKeys []string, key ssh.PublicKey) bool {
+	for _, pubkey := range authorizedKeys {
+		parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubkey))
+		if err != nil {
+			log.Warn("failed to parse authorized key", "key", pubkey, "err", err)
+		}
+		if ssh.KeysEqual(key, parsed) {
+			return true
+		}
+	}
+
+	return false
 }
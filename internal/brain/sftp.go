@@ -0,0 +1,433 @@
+package brain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/pkg/sftp"
+)
+
+// maxSFTPFileSize limits the size of a file written over SFTP as writes are
+// buffered in memory.
+const maxSFTPFileSize = 32 << 20
+
+var ErrFileTooLarge = fmt.Errorf("files larger than %d bytes cannot be written over SFTP", maxSFTPFileSize)
+
+// sftpHandler exposes the content dir over SFTP. Writes are buffered until
+// the file is closed so they can be validated and saved like NEW.
+//
+// Files that are neither brainfiles nor attachments, such as the swap,
+// backup and temporary files editors write while saving, are kept in a
+// scratch dir for the session so saving by renaming a temporary file over a
+// brainfile works.
+type sftpHandler struct {
+	b       *Brain
+	s       ssh.Session
+	scratch string
+}
+
+// relPath converts an SFTP request path into a path relative to the content dir.
+func relPath(r *sftp.Request) string {
+	return cleanSFTPPath(r.Filepath)
+}
+
+func cleanSFTPPath(p string) string {
+	rel := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if rel == "" {
+		return "."
+	}
+
+	return rel
+}
+
+func isScratch(rel string) bool {
+	return !strings.HasSuffix(rel, ".md") && !InAssetsDir(rel)
+}
+
+// filePath returns the path of rel in the scratch dir if it is a scratch
+// file that exists, otherwise in the content dir.
+func (h *sftpHandler) filePath(rel string) (string, error) {
+	if isScratch(rel) {
+		scratchPath := filepath.Join(h.scratch, rel)
+		_, err := os.Stat(scratchPath)
+		if err == nil {
+			return scratchPath, nil
+		}
+	}
+
+	return h.b.contentPath(rel)
+}
+
+func (h *sftpHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	filePath, err := h.filePath(relPath(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filePath)
+}
+
+func (h *sftpHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	rel := relPath(r)
+	filePath, err := h.filePath(rel)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &sftpWriter{h: h, relPath: rel}
+	if isScratch(rel) {
+		w.scratchPath = filepath.Join(h.scratch, rel)
+	}
+
+	if !r.Pflags().Trunc {
+		existing, err := os.ReadFile(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		w.data = existing
+	}
+
+	return w, nil
+}
+
+func (h *sftpHandler) Filecmd(r *sftp.Request) error {
+	rel := relPath(r)
+	switch r.Method {
+	case "Setstat":
+		return h.setstat(r, rel)
+	case "Rename", "PosixRename":
+		target := cleanSFTPPath(r.Target)
+		err := h.rename(rel, target)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("moved %s to %s", rel, target)
+		return nil
+	case "Remove":
+		if isScratch(rel) {
+			filePath, err := h.filePath(rel)
+			if err != nil {
+				return err
+			}
+
+			return os.Remove(filePath)
+		}
+
+		var err error
+		if InAssetsDir(rel) {
+			err = h.b.deleteAttachment(h.s, rel)
+		} else {
+			err = h.b.deleteNode(h.s, rel)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		log.Infof("deleted %s", rel)
+		return nil
+	case "Mkdir":
+		dirPath, err := h.b.contentPath(rel)
+		if err != nil {
+			return err
+		}
+
+		return os.Mkdir(dirPath, 0770)
+	case "Rmdir":
+		dirPath, err := h.b.contentPath(rel)
+		if err != nil {
+			return err
+		}
+
+		return os.Remove(dirPath)
+	}
+
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+// rename moves a brainfile or attachment, or saves a scratch file renamed
+// over a brainfile as editors do when saving.
+func (h *sftpHandler) rename(rel, target string) error {
+	if InAssetsDir(rel) || InAssetsDir(target) {
+		if !InAssetsDir(rel) || !InAssetsDir(target) {
+			return fmt.Errorf("attachments can only be renamed to another attachment, not %s to %s", rel, target)
+		}
+
+		return h.renameAttachment(rel, target)
+	}
+
+	if !isScratch(rel) {
+		if isScratch(target) {
+			return fmt.Errorf("%s can only be renamed to a brainfile", rel)
+		}
+
+		return h.b.moveNode(h.s, rel, target)
+	}
+
+	from, err := h.filePath(rel)
+	if err != nil {
+		return err
+	}
+
+	if isScratch(target) {
+		to := filepath.Join(h.scratch, target)
+		err = os.MkdirAll(filepath.Dir(to), 0700)
+		if err != nil {
+			return err
+		}
+
+		return os.Rename(from, to)
+	}
+
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+
+	err = h.b.saveNode(h.s, target, data)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(from)
+}
+
+// renameAttachment saves the attachment at rel as target before deleting it.
+func (h *sftpHandler) renameAttachment(rel, target string) error {
+	from, err := h.b.contentPath(rel)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+
+	err = h.b.saveAttachmentPath(h.s, target, data)
+	if err != nil {
+		return err
+	}
+
+	return h.b.deleteAttachment(h.s, rel)
+}
+
+// setstat applies size and time changes. The permissions and owner of
+// files in the content dir are managed by the server.
+func (h *sftpHandler) setstat(r *sftp.Request, rel string) error {
+	flags := r.AttrFlags()
+	attrs := r.Attributes()
+	filePath, err := h.filePath(rel)
+	if err != nil {
+		return err
+	}
+
+	scratch := strings.HasPrefix(filePath, h.scratch+string(filepath.Separator))
+	if !scratch && (flags.Permissions || flags.UidGid) {
+		return sftp.ErrSSHFxOpUnsupported
+	}
+
+	if flags.Size {
+		if attrs.Size > maxSFTPFileSize {
+			return ErrFileTooLarge
+		}
+
+		switch {
+		case scratch:
+			err = os.Truncate(filePath, int64(attrs.Size))
+		case InAssetsDir(rel):
+			var data []byte
+			data, err = os.ReadFile(filePath)
+			if err == nil {
+				err = h.b.saveAttachmentPath(h.s, rel, resize(data, int(attrs.Size)))
+			}
+		default:
+			err = h.b.updateNode(h.s, rel, func(data []byte) ([]byte, error) {
+				return resize(data, int(attrs.Size)), nil
+			})
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if flags.Permissions {
+		err = os.Chmod(filePath, attrs.FileMode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+
+	if flags.Acmodtime {
+		return os.Chtimes(filePath, attrs.AccessTime(), attrs.ModTime())
+	}
+
+	return nil
+}
+
+// resize truncates data to size or pads it with zeros.
+func resize(data []byte, size int) []byte {
+	if size <= len(data) {
+		return data[:size]
+	}
+
+	return append(data, make([]byte, size-len(data))...)
+}
+
+func (h *sftpHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	filePath, err := h.filePath(relPath(r))
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		entries, err := os.ReadDir(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		var infos listerAt
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+
+			infos = append(infos, info)
+		}
+
+		return infos, nil
+	case "Stat":
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		return listerAt{info}, nil
+	}
+
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+type sftpWriter struct {
+	mu      sync.Mutex
+	h       *sftpHandler
+	relPath string
+	data    []byte
+	// scratchPath is set when writing a scratch file.
+	scratchPath string
+}
+
+func (w *sftpWriter) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if off < 0 || off+int64(len(p)) > maxSFTPFileSize {
+		return 0, ErrFileTooLarge
+	}
+
+	end := int(off) + len(p)
+	if end > len(w.data) {
+		w.data = resize(w.data, end)
+	}
+
+	copy(w.data[off:], p)
+	return len(p), nil
+}
+
+// Close validates and saves the written brainfile or attachment, or writes
+// the scratch file.
+func (w *sftpWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.scratchPath != "" {
+		err := os.MkdirAll(filepath.Dir(w.scratchPath), 0700)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(w.scratchPath, w.data, 0600)
+	}
+
+	var err error
+	if InAssetsDir(w.relPath) {
+		err = w.h.b.saveAttachmentPath(w.h.s, w.relPath, w.data)
+	} else {
+		err = w.h.b.saveNode(w.h.s, w.relPath, w.data)
+	}
+
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	log.Infof("saved %s", w.relPath)
+	return nil
+}
+
+func (b *Brain) sftpSubsystem(s ssh.Session) {
+	sessionsTotal.Inc()
+	sessionsActive.Inc()
+	defer sessionsActive.Dec()
+
+	target, err := b.sessionBrain(s)
+	if err != nil {
+		log.Error(err)
+		wish.Errorf(s, "ERROR: %s\n", err)
+		s.Exit(1)
+		return
+	}
+
+	scratch, err := os.MkdirTemp("", "brain-sftp-")
+	if err != nil {
+		log.Error(err)
+		wish.Errorf(s, "ERROR: %s\n", err)
+		s.Exit(1)
+		return
+	}
+	defer os.RemoveAll(scratch)
+
+	h := &sftpHandler{b: target, s: s, scratch: scratch}
+	server := sftp.NewRequestServer(s, sftp.Handlers{
+		FileGet:  h,
+		FilePut:  h,
+		FileCmd:  h,
+		FileList: h,
+	})
+
+	err = server.Serve()
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error("sftp server error", "err", err)
+	}
+
+	server.Close()
+}
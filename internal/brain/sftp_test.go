@@ -0,0 +1,204 @@
+package brain
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// newSFTPClient serves b over SFTP to the returned client.
+func newSFTPClient(t *testing.T, b *Brain) *sftp.Client {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	h := &sftpHandler{b: b, s: newTestSession(""), scratch: t.TempDir()}
+	server := sftp.NewRequestServer(serverConn, sftp.Handlers{
+		FileGet:  h,
+		FilePut:  h,
+		FileCmd:  h,
+		FileList: h,
+	})
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return client
+}
+
+func writeSFTPFile(client *sftp.Client, path, data string) error {
+	f, err := client.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write([]byte(data))
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func TestSFTP(t *testing.T) {
+	b := newTestBrain(t, map[string]string{"a.md": "---\ntitle: A\n---\n"})
+	client := newSFTPClient(t, b)
+
+	err := writeSFTPFile(client, "/nested/b.md", "---\ntitle: B\n---\nbody\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.config.ContentDir, "nested", "b.md"))
+	if err != nil || !strings.Contains(string(data), "created:") || !strings.HasSuffix(string(data), "body\n") {
+		t.Errorf("expected b.md to be saved and stamped got %q %v", data, err)
+	}
+
+	err = writeSFTPFile(client, "/invalid.md", "---\ntitle: [\n---\n")
+	if err == nil {
+		t.Error("expected an invalid brainfile to be rejected")
+	}
+
+	// Editors save by writing a temporary file and renaming it over the
+	// brainfile, leaving swap files alongside it
+	err = writeSFTPFile(client, "/.a.md.swp", "swap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writeSFTPFile(client, "/a.md.tmp", "---\ntitle: A edited\n---\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.PosixRename("/a.md.tmp", "/a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = os.ReadFile(filepath.Join(b.config.ContentDir, "a.md"))
+	if err != nil || !strings.Contains(string(data), "title: A edited") {
+		t.Errorf("expected renamed temporary file to be saved got %q %v", data, err)
+	}
+
+	if _, err := client.Stat("/a.md.tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected temporary file to be removed got %v", err)
+	}
+
+	if _, err := client.Stat("/.a.md.swp"); err != nil {
+		t.Errorf("expected swap file to exist got %v", err)
+	}
+
+	err = client.Remove("/.a.md.swp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(b.config.ContentDir)
+	if err != nil || len(entries) != 2 {
+		t.Errorf("expected scratch files to stay out of the content dir got %v %v", entries, err)
+	}
+
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	err = client.Chtimes("/a.md", mtime, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(b.config.ContentDir, "a.md"))
+	if err != nil || !info.ModTime().Equal(mtime) {
+		t.Errorf("expected mtime to be set got %v %v", info, err)
+	}
+
+	err = client.Chmod("/a.md", 0777)
+	if err == nil {
+		t.Error("expected changing the permissions of a brainfile to fail")
+	}
+}
+
+func TestSFTPAttachments(t *testing.T) {
+	b := newTestBrain(t, map[string]string{"ops/deploy.md": "---\ntitle: Deploy\n---\n"})
+	entries := auditEntries(t, b)
+	client := newSFTPClient(t, b)
+
+	err := writeSFTPFile(client, "/ops/deploy.assets/diagram.png", "png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.config.ContentDir, "ops", "deploy.assets", "diagram.png"))
+	if err != nil || string(data) != "png" {
+		t.Errorf("expected the attachment to be saved as is got %q %v", data, err)
+	}
+
+	err = writeSFTPFile(client, "/ops/missing.assets/diagram.png", "png")
+	if err == nil {
+		t.Error("expected an attachment without a brainfile to be rejected")
+	}
+
+	err = client.Rename("/ops/deploy.assets/diagram.png", "/ops/diagram.md")
+	if err == nil {
+		t.Error("expected renaming an attachment to a brainfile to fail")
+	}
+
+	err = client.PosixRename("/ops/deploy.assets/diagram.png", "/ops/deploy.assets/overview.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Remove("/ops/deploy.assets/overview.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = os.Stat(filepath.Join(b.config.ContentDir, "ops", "deploy.assets"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected the attachments directory to be removed got %v", err)
+	}
+
+	var actions []string
+	for _, entry := range entries() {
+		actions = append(actions, entry.Action+" "+entry.Path)
+	}
+
+	expected := []string{
+		ATTACH + " ops/deploy.assets/diagram.png",
+		ATTACH + " ops/missing.assets/diagram.png",
+		ATTACH + " ops/deploy.assets/overview.png",
+		DELETE + " ops/deploy.assets/diagram.png",
+		DELETE + " ops/deploy.assets/overview.png",
+	}
+	if !slices.Equal(actions, expected) {
+		t.Errorf("expected attachment changes to be audited as %v got %v", expected, actions)
+	}
+}
+
+func TestSFTPWriteLimit(t *testing.T) {
+	b := newTestBrain(t, nil)
+	client := newSFTPClient(t, b)
+
+	f, err := client.Create("/a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, err = f.WriteAt([]byte("x"), 1<<40)
+	if err == nil {
+		t.Error("expected a write past the size limit to fail")
+	}
+}
@@ -1,36 +1,45 @@
 package brain
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
+	"github.com/spf13/pflag"
 )
 
 const (
-	NEW    string = "new"
-	LIST   string = "list"
-	EDIT   string = "edit"
-	MOVE   string = "move"
-	DELETE string = "delete"
+	NEW      string = "new"
+	LIST     string = "list"
+	EDIT     string = "edit"
+	MOVE     string = "move"
+	DELETE   string = "delete"
+	STATUS   string = "status"
+	AUDIT    string = "audit"
+	SHOW     string = "show"
+	MANIFEST string = "manifest"
+	ATTACH   string = "attach"
+	APPEND   string = "append"
+	PATCH    string = "patch"
+
+	TEMPLATES string = "templates"
+	TEMPLATE  string = "template"
+
+	TASKS     string = "tasks"
+	TASK_DONE string = "task-done"
+
+	EXPORT         string = "export"
+	IMPORT_ARCHIVE string = "import-archive"
 )
 
-func listNodes(sb *strings.Builder, nodes []*Node) {
-	for _, node := range nodes {
-		if node.IsDir {
-			listNodes(sb, node.Children)
-		} else {
-			fmt.Fprintf(sb, "%s\n", node.Path)
-		}
-	}
-}
-
 func isEmptyDir(name string) (bool, error) {
 	fi, err := os.Stat(name)
 	if err != nil {
@@ -83,160 +92,307 @@ func removeEmptyDirs(baseDir, relPath string) error {
 	return nil
 }
 
+func requireArgs(s ssh.Session, n int) error {
+	if len(s.Command())-1 < n {
+		return fmt.Errorf("%s requires %d argument(s)", s.Command()[0], n)
+	}
+
+	return nil
+}
+
+func (b *Brain) handleNew(s ssh.Session) error {
+	err := requireArgs(s, 1)
+	if err != nil {
+		return err
+	}
+
+	relPath, anchor := SplitAnchor(s.Command()[1])
+	data, err := io.ReadAll(s)
+	if err != nil {
+		return err
+	}
+
+	if anchor != "" {
+		err = b.updateNode(s, filepath.Clean(relPath), func(current []byte) ([]byte, error) {
+			return replaceSection(current, anchor, data)
+		})
+	} else {
+		err = b.saveNode(s, relPath, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Infof("saved %s", relPath)
+	wish.Printf(s, "OK: saved %s\n", relPath)
+
+	return nil
+}
+
+// sortNodes sorts nodes by path, title, or most recently created or updated.
+func sortNodes(nodes []*Node, by string) error {
+	switch by {
+	case "", "path":
+	case "title":
+		slices.SortStableFunc(nodes, func(a, b *Node) int { return strings.Compare(a.Title, b.Title) })
+	case CreatedField:
+		slices.SortStableFunc(nodes, func(a, b *Node) int { return b.Created.Compare(a.Created) })
+	case UpdatedField:
+		slices.SortStableFunc(nodes, func(a, b *Node) int { return b.LastUpdated().Compare(a.LastUpdated()) })
+	default:
+		return fmt.Errorf("cannot sort by %q, use path, title, created or updated", by)
+	}
+
+	return nil
+}
+
+func (b *Brain) handleList(s ssh.Session) error {
+	flags := pflag.NewFlagSet(LIST, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	sortBy := flags.String("sort", "", "")
+	since := flags.String("since", "", "")
+	long := flags.BoolP("long", "l", false, "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	sinceTime, err := parseTime(*since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	nodes := slices.DeleteFunc(b.tree.files(""), func(node *Node) bool {
+		return node.LastUpdated().Before(sinceTime)
+	})
+
+	err = sortNodes(nodes, *sortBy)
+	if err != nil {
+		return err
+	}
+
+	sb := &strings.Builder{}
+	for _, node := range nodes {
+		if *long {
+			updatedBy := node.UpdatedBy
+			if updatedBy == "" {
+				updatedBy = "-"
+			}
+
+			fmt.Fprintf(sb, "%s\t%s\t%s\n", node.LastUpdated().Format(time.RFC3339), updatedBy, node.Path)
+		} else {
+			fmt.Fprintf(sb, "%s\n", node.Path)
+		}
+	}
+
+	log.Info("listed nodes")
+	wish.Print(s, sb)
+
+	return nil
+}
+
+func (b *Brain) handleStatus(s ssh.Session) error {
+	log.Info("sent status")
+	wish.Print(s, b.status.get().String())
+
+	return nil
+}
+
+func (b *Brain) handleEdit(s ssh.Session) (err error) {
+	entry := newAuditEntry(s, EDIT)
+	defer func() { b.audit(entry, err) }()
+
+	err = requireArgs(s, 1)
+	if err != nil {
+		return err
+	}
+
+	relPath, anchor := SplitAnchor(filepath.Clean(s.Command()[1]))
+	entry.Path = relPath
+	node, err := b.tree.Find(relPath)
+	if err != nil {
+		return err
+	}
+
+	entry.HashBefore = hashContent(node.Raw)
+	data := node.Raw
+	if anchor != "" {
+		data, err = section(node.Raw, anchor)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("sent %s for editing", s.Command()[1])
+	wish.Print(s, string(data))
+
+	return nil
+}
+
+func (b *Brain) handleShow(s ssh.Session) error {
+	flags := pflag.NewFlagSet(SHOW, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	html := flags.Bool("html", false, "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%s requires 1 argument(s)", SHOW)
+	}
+
+	if InAssetsDir(filepath.Clean(flags.Arg(0))) {
+		return b.showAttachment(s, filepath.Clean(flags.Arg(0)))
+	}
+
+	relPath, anchor := SplitAnchor(filepath.Clean(flags.Arg(0)))
+	node, err := b.tree.Find(relPath)
+	if err != nil {
+		return err
+	}
+
+	raw, content := node.Raw, node.Content
+	if anchor != "" {
+		raw, err = section(node.Raw, anchor)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		err = markdownParser.Convert(raw, &buf)
+		if err != nil {
+			return err
+		}
+
+		content = buf.Bytes()
+	}
+
+	log.Infof("sent %s for showing", flags.Arg(0))
+	if *html {
+		wish.Print(s, string(inlineImages(content, relPath, b.config.ContentDir)))
+	} else {
+		wish.Print(s, string(raw))
+	}
+
+	return nil
+}
+
+func (b *Brain) handleMove(s ssh.Session) error {
+	err := requireArgs(s, 2)
+	if err != nil {
+		return err
+	}
+
+	fromPathRel := filepath.Clean(s.Command()[1])
+	toPathRel := filepath.Clean(s.Command()[2])
+	err = b.moveNode(s, fromPathRel, toPathRel)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("moved %s to %s", fromPathRel, toPathRel)
+	wish.Printf(s, "OK: moved %s to %s\n", fromPathRel, toPathRel)
+
+	return nil
+}
+
+func (b *Brain) handleDelete(s ssh.Session) error {
+	err := requireArgs(s, 1)
+	if err != nil {
+		return err
+	}
+
+	relPath := filepath.Clean(s.Command()[1])
+	if InAssetsDir(relPath) {
+		err = b.deleteAttachment(s, relPath)
+	} else {
+		err = b.deleteNode(s, relPath)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log.Infof("deleted %s", relPath)
+	wish.Printf(s, "OK: deleted %s\n", relPath)
+
+	return nil
+}
+
+func (b *Brain) commandHandlers() map[string]func(ssh.Session) error {
+	return map[string]func(ssh.Session) error{
+		NEW:      b.handleNew,
+		LIST:     b.handleList,
+		STATUS:   b.handleStatus,
+		EDIT:     b.handleEdit,
+		MOVE:     b.handleMove,
+		DELETE:   b.handleDelete,
+		AUDIT:    b.handleAudit,
+		SHOW:     b.handleShow,
+		MANIFEST: b.handleManifest,
+		ATTACH:   b.handleAttach,
+		APPEND:   b.handleAppend,
+		PATCH:    b.handlePatch,
+
+		TEMPLATES: b.handleTemplates,
+		TEMPLATE:  b.handleTemplate,
+
+		TASKS:     b.handleTasks,
+		TASK_DONE: b.handleTaskDone,
+
+		EXPORT:         b.handleExport,
+		IMPORT_ARCHIVE: b.handleImportArchive,
+
+		GIT_UPLOAD_PACK:  b.handleUploadPack,
+		GIT_RECEIVE_PACK: b.handleReceivePack,
+	}
+}
+
 func (b *Brain) sshHandler(next ssh.Handler) ssh.Handler {
+	handlers := map[*Brain]map[string]func(ssh.Session) error{b: b.commandHandlers()}
+	for _, nb := range b.brains {
+		handlers[nb] = nb.commandHandlers()
+	}
+
 	return func(s ssh.Session) {
+		sessionsTotal.Inc()
+		sessionsActive.Inc()
+		defer sessionsActive.Dec()
+
+		var command string
 		if len(s.Command()) > 0 {
-			switch s.Command()[0] {
-			case NEW:
-				relPath := s.Command()[1]
-				if !strings.HasSuffix(relPath, ".md") {
-					log.Error("brainfile path must end with \".md\"")
-					wish.Println(s, "ERROR: brainfile path must end with \".md\"")
-					break
-				}
-
-				data, err := io.ReadAll(s)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				// Validate the data
-				_, err = NewNodeFromBytes(data)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				newFilePath := filepath.Join(b.config.ContentDir, relPath)
-				err = os.MkdirAll(filepath.Dir(newFilePath), 0770)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				err = os.WriteFile(newFilePath, data, 0644)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				b.updater <- struct{}{}
-				log.Infof("saved %s", relPath)
-				wish.Printf(s, "OK: saved %s\n", relPath)
-
-			case LIST:
-				sb := &strings.Builder{}
-				listNodes(sb, b.tree.nodes)
-				log.Info("listed nodes")
-				wish.Print(s, sb)
-
-			case EDIT:
-				relPath := filepath.Clean(s.Command()[1])
-				node, err := b.tree.Find(relPath)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				log.Infof("sent %s for editing", relPath)
-				wish.Print(s, string(node.Raw))
-
-			case MOVE:
-				fromPathRel := filepath.Clean(s.Command()[1])
-				toPathRel := filepath.Clean(s.Command()[2])
-				if fromPathRel == toPathRel {
-					log.Infof("moved %s to %s", fromPathRel, toPathRel)
-					wish.Printf(s, "OK: moved %s to %s\n", fromPathRel, toPathRel)
-					break
-				}
-
-				fromPath := filepath.Join(b.config.ContentDir, fromPathRel)
-				toPath := filepath.Join(b.config.ContentDir, toPathRel)
-
-				_, err := os.Stat(toPath)
-				if err == nil {
-					log.Errorf("%s already exists, move must be non-destructive", toPathRel)
-					wish.Printf(s, "ERROR: %s already exists, move must be non-destructive\n", toPathRel)
-					b.updater <- struct{}{}
-					break
-				}
-
-				if !os.IsNotExist(err) {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				fromNode, err := b.tree.Find(fromPathRel)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					b.updater <- struct{}{}
-					break
-				}
-
-				err = os.MkdirAll(path.Dir(toPath), 0770)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				err = os.WriteFile(toPath, fromNode.Raw, 0644)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				err = os.Remove(fromPath)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				fromDirRel := filepath.Dir(fromPathRel)
-				err = removeEmptyDirs(b.config.ContentDir, fromDirRel)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				b.updater <- struct{}{}
-				log.Infof("moved %s to %s", fromPathRel, toPathRel)
-				wish.Printf(s, "OK: moved %s to %s\n", fromPathRel, toPathRel)
-
-			case DELETE:
-				relPath := filepath.Clean(s.Command()[1])
-				path := filepath.Join(b.config.ContentDir, relPath)
-				err := os.Remove(path)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				fromDirRel := filepath.Dir(relPath)
-				err = removeEmptyDirs(b.config.ContentDir, fromDirRel)
-				if err != nil {
-					log.Error(err)
-					wish.Printf(s, "ERROR: %s\n", err)
-					break
-				}
-
-				b.updater <- struct{}{}
-				log.Infof("deleted %s", relPath)
-				wish.Printf(s, "OK: deleted %s\n", relPath)
+			command = s.Command()[0]
+		}
+
+		target, err := b.sessionBrain(s)
+		if err != nil {
+			log.Error(err)
+			reportError(s, command, err)
+			return
+		}
+
+		handler, ok := handlers[target][command]
+		if ok {
+			err := handler(s)
+			if err != nil {
+				log.Error(err)
+				reportError(s, command, err)
 			}
+
+			commandsTotal.WithLabelValues(command, result(err)).Inc()
 		}
 		next(s)
 	}
 }
+
+func reportError(s ssh.Session, command string, err error) {
+	if command == "" || strings.HasPrefix(command, "git-") || command == EXPORT {
+		// Git clients only display stderr and archives are streamed to
+		// stdout
+		wish.Errorf(s, "ERROR: %s\n", err)
+		s.Exit(1)
+	} else {
+		wish.Printf(s, "ERROR: %s\n", err)
+	}
+}
@@ -0,0 +1,95 @@
+package brain
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Status struct {
+	Degraded       bool              `json:"degraded"`
+	Loaded         bool              `json:"loaded"`
+	LastUpdate     time.Time         `json:"lastUpdate"`
+	LastSuccess    time.Time         `json:"lastSuccess"`
+	Error          string            `json:"error,omitempty"`
+	PathErrors     map[string]string `json:"pathErrors,omitempty"`
+	InvalidNodes   map[string]string `json:"invalidNodes,omitempty"`
+	UpdateTaskErrs map[string]string `json:"updateTaskErrors,omitempty"`
+	// Brains is the status of each named brain served alongside the default.
+	Brains map[string]Status `json:"brains,omitempty"`
+}
+
+type status struct {
+	mu sync.RWMutex
+	Status
+}
+
+func (s *status) get() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Status
+}
+
+func (s *status) treeFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LastUpdate = time.Now().UTC()
+	s.Error = err.Error()
+	s.Degraded = true
+}
+
+func (s *status) treeUpdated(errs *buildErrors) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LastUpdate = time.Now().UTC()
+	s.LastSuccess = s.LastUpdate
+	s.Loaded = true
+	s.Error = ""
+	s.PathErrors = errs.paths
+	s.InvalidNodes = errs.invalid
+	s.Degraded = len(s.PathErrors) > 0 || len(s.UpdateTaskErrs) > 0
+}
+
+func (s *status) tasksRan(taskErrs map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.UpdateTaskErrs = taskErrs
+	s.Degraded = s.Error != "" || len(s.PathErrors) > 0 || len(s.UpdateTaskErrs) > 0
+}
+
+func writeErrors(sb *strings.Builder, heading string, errs map[string]string) {
+	if len(errs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "%s:\n", heading)
+	for _, key := range slices.Sorted(maps.Keys(errs)) {
+		fmt.Fprintf(sb, "  %s: %s\n", key, errs[key])
+	}
+}
+
+func (s Status) String() string {
+	sb := &strings.Builder{}
+	state := "ok"
+	if !s.Loaded {
+		state = "loading"
+	} else if s.Degraded {
+		state = "degraded"
+	}
+
+	fmt.Fprintf(sb, "status: %s\n", state)
+	fmt.Fprintf(sb, "last update: %s\n", s.LastUpdate.Format(time.RFC3339))
+	fmt.Fprintf(sb, "last successful update: %s\n", s.LastSuccess.Format(time.RFC3339))
+	if s.Error != "" {
+		fmt.Fprintf(sb, "error: %s\n", s.Error)
+	}
+
+	writeErrors(sb, "path errors", s.PathErrors)
+	writeErrors(sb, "invalid brainfiles", s.InvalidNodes)
+	writeErrors(sb, "update task errors", s.UpdateTaskErrs)
+
+	return sb.String()
+}
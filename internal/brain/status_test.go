@@ -0,0 +1,139 @@
+package brain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedrw/brain/internal/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBuildErrors(t *testing.T) {
+	b := newTestBrain(t, map[string]string{
+		"a.md":                "---\ntitle: A\n---\n",
+		"nested/b.md":         "---\ntitle: B\n---\n",
+		"nested/bad.md":       "no frontmatter\n",
+		"orphan.assets/a.png": "png",
+	})
+
+	err := os.Symlink(filepath.Join(b.config.ContentDir, "missing.md"), filepath.Join(b.config.ContentDir, "broken.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.getTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := b.status.get()
+	if _, ok := status.InvalidNodes["nested/bad.md"]; !ok || len(status.InvalidNodes) != 1 {
+		t.Errorf("expected nested/bad.md to be invalid got %v", status.InvalidNodes)
+	}
+
+	if _, ok := status.PathErrors["broken.md"]; !ok || len(status.PathErrors) != 1 {
+		t.Errorf("expected broken.md to be a path error got %v", status.PathErrors)
+	}
+
+	if !status.Loaded || !status.Degraded {
+		t.Errorf("expected loaded and degraded status got %+v", status)
+	}
+
+	if paths := b.tree.Paths(); len(paths) != 2 {
+		t.Errorf("expected the valid brainfiles to be served got %v", paths)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s := &status{}
+	if !strings.HasPrefix(s.get().String(), "status: loading\n") {
+		t.Errorf("expected loading status got %q", s.get())
+	}
+
+	s.treeUpdated(newBuildErrors())
+	if !strings.HasPrefix(s.get().String(), "status: ok\n") {
+		t.Errorf("expected ok status got %q", s.get())
+	}
+
+	s.tasksRan(map[string]string{"mkdocs build": "exit status 1"})
+	s.treeFailed(errors.New("content dir missing"))
+	out := s.get().String()
+	for _, expected := range []string{"status: degraded\n", "error: content dir missing\n", "update task errors:\n  mkdocs build: exit status 1\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in %q", expected, out)
+		}
+	}
+
+	// A later successful update clears the error but not task errors
+	s.treeUpdated(newBuildErrors())
+	if status := s.get(); status.Error != "" || !status.Degraded {
+		t.Errorf("expected error cleared and still degraded got %+v", status)
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUpdaterRetries(t *testing.T) {
+	backoffMin := retryBackoffMin
+	retryBackoffMin = time.Millisecond
+	t.Cleanup(func() { retryBackoffMin = backoffMin })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	contentDir := filepath.Join(t.TempDir(), "docs")
+	b := &Brain{
+		ctx:    ctx,
+		name:   "updater",
+		config: config.Config{Server: config.Server{ContentDir: contentDir, UpdateTasks: []string{"false"}}},
+		tree:   &Tree{},
+		status: &status{},
+	}
+
+	failures := treeUpdateFailures.WithLabelValues(b.name)
+	before := testutil.ToFloat64(failures)
+	b.updater = b.Updater()
+	b.update()
+	waitFor(t, "tree update to be retried", func() bool { return testutil.ToFloat64(failures)-before >= 2 })
+
+	rec := httptest.NewRecorder()
+	b.healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected healthz to fail while the tree fails to load got %d", rec.Code)
+	}
+
+	// The retry picks up the content dir once it exists
+	err := os.MkdirAll(contentDir, 0770)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, "update tasks to run", func() bool { return len(b.status.get().UpdateTaskErrs) > 0 })
+	status := b.status.get()
+	if !status.Loaded || status.Error != "" || status.UpdateTaskErrs["false"] == "" {
+		t.Errorf("expected the tree to load and the task to fail got %+v", status)
+	}
+
+	rec = httptest.NewRecorder()
+	b.healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected healthz to pass once the tree loads got %d", rec.Code)
+	}
+}
@@ -0,0 +1,315 @@
+package brain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/spf13/pflag"
+)
+
+const dueAnnotation = "due"
+
+var (
+	ErrNotTask     = errors.New("not an open task")
+	ErrTaskChanged = errors.New("task has changed, list tasks again")
+
+	taskRegexp       = regexp.MustCompile(`^(\s*[-*+] \[)([ xX])(\]\s+)(.*)$`)
+	annotationRegexp = regexp.MustCompile(`(^|\s)@([\w.-]+)(\(([^)]*)\))?`)
+)
+
+// Task is a task list item in a brainfile, annotated with @due(YYYY-MM-DD)
+// and @owner.
+type Task struct {
+	Path  string
+	Line  int
+	Text  string
+	Done  bool
+	Owner string
+	Due   time.Time
+}
+
+// ID identifies the task by its path, line number and a hash of its text, so
+// an ID listed before the brainfile changed can't tick off another task.
+func (t Task) ID() string {
+	return fmt.Sprintf("%s:%d#%s", t.Path, t.Line, t.hash())
+}
+
+func (t Task) hash() string {
+	return hashContent([]byte(t.Text))[:8]
+}
+
+func fence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// parseTasks returns the task list items in a brainfile outside of its
+// frontmatter and code blocks, numbered by line in data.
+func parseTasks(data []byte) []Task {
+	var tasks []Task
+	inFrontmatter, inCode := false, false
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		switch {
+		case i == 0 && line == "---":
+			inFrontmatter = true
+			continue
+		case inFrontmatter:
+			inFrontmatter = line != "---"
+			continue
+		case fence(line):
+			inCode = !inCode
+			continue
+		case inCode:
+			continue
+		}
+
+		match := taskRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		task := Task{
+			Line: i + 1,
+			Done: match[2] != " ",
+		}
+
+		for _, annotation := range annotationRegexp.FindAllStringSubmatch(match[4], -1) {
+			name, value := annotation[2], annotation[4]
+			switch {
+			case name == dueAnnotation && annotation[3] != "":
+				task.Due, _ = time.Parse(time.DateOnly, value)
+			case annotation[3] == "" && task.Owner == "":
+				task.Owner = name
+			}
+		}
+
+		text := annotationRegexp.ReplaceAllString(match[4], "$1")
+		task.Text = strings.Join(strings.Fields(text), " ")
+		tasks = append(tasks, task)
+	}
+
+	return tasks
+}
+
+// collectTasks returns the tasks of every brainfile in nodes.
+func collectTasks(nodes []*Node) []Task {
+	var tasks []Task
+	for _, node := range nodes {
+		if node.IsDir {
+			tasks = append(tasks, collectTasks(node.Children)...)
+			continue
+		}
+
+		for _, task := range node.Tasks {
+			task.Path = node.Path
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks
+}
+
+// Tasks returns the tasks across the tree.
+func (t *Tree) Tasks() []Task {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return slices.Clone(t.tasks)
+}
+
+// completeTask ticks off the open task with hash on line of data, or
+// wherever it has moved to if it's the only open task with hash.
+func completeTask(data []byte, line int, hash string) ([]byte, error) {
+	var matches []Task
+	changed := false
+	for _, task := range parseTasks(data) {
+		if task.Done {
+			continue
+		}
+
+		if task.hash() != hash {
+			changed = changed || task.Line == line
+			continue
+		}
+
+		if task.Line == line {
+			matches = []Task{task}
+			break
+		}
+
+		matches = append(matches, task)
+	}
+
+	switch {
+	case len(matches) == 1:
+		line = matches[0].Line
+	case changed || len(matches) > 1:
+		return nil, fmt.Errorf("%w on line %d", ErrTaskChanged, line)
+	default:
+		return nil, fmt.Errorf("%w on line %d", ErrNotTask, line)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	lines[line-1] = taskRegexp.ReplaceAll(lines[line-1], []byte("${1}x${3}${4}"))
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// parseTaskID splits a task ID into its path, line number and text hash.
+func parseTaskID(id string) (string, int, string, error) {
+	invalid := fmt.Errorf("invalid task %q, use the id listed by tasks", id)
+	j := strings.LastIndex(id, "#")
+	if j == -1 || j == len(id)-1 {
+		return "", 0, "", invalid
+	}
+
+	location, hash := id[:j], id[j+1:]
+
+	i := strings.LastIndex(location, ":")
+	if i == -1 {
+		return "", 0, "", invalid
+	}
+
+	line, err := strconv.Atoi(location[i+1:])
+	if err != nil {
+		return "", 0, "", invalid
+	}
+
+	return filepath.Clean(location[:i]), line, hash, nil
+}
+
+// parseDue parses a --due date, or a duration such as 7d looking ahead from
+// now.
+func parseDue(value string, now time.Time) (time.Time, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err == nil || value == "" {
+		return t, nil
+	}
+
+	t, err = parseTime(value, now)
+	if err != nil {
+		return t, err
+	}
+
+	return now.Add(now.Sub(t)), nil
+}
+
+// filterTasks returns the tasks matching the owner, due by and path filters,
+// including completed tasks if all is set.
+func filterTasks(tasks []Task, owner string, due time.Time, path string, all bool) []Task {
+	owner = strings.TrimPrefix(owner, "@")
+	path = strings.TrimSuffix(path, "/")
+	return slices.DeleteFunc(tasks, func(task Task) bool {
+		switch {
+		case task.Done && !all:
+			return true
+		case owner != "" && task.Owner != owner:
+			return true
+		case !due.IsZero() && (task.Due.IsZero() || task.Due.After(due)):
+			return true
+		case path != "" && task.Path != path && !strings.HasPrefix(task.Path, path+"/"):
+			return true
+		}
+
+		return false
+	})
+}
+
+// sortTasks orders tasks by due date, undated last, then by where they are.
+func sortTasks(tasks []Task) {
+	slices.SortStableFunc(tasks, func(a, b Task) int {
+		switch {
+		case a.Due.IsZero() != b.Due.IsZero():
+			if a.Due.IsZero() {
+				return 1
+			}
+
+			return -1
+		case !a.Due.Equal(b.Due):
+			return a.Due.Compare(b.Due)
+		case a.Path != b.Path:
+			return strings.Compare(a.Path, b.Path)
+		}
+
+		return a.Line - b.Line
+	})
+}
+
+func (b *Brain) handleTasks(s ssh.Session) error {
+	flags := pflag.NewFlagSet(TASKS, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	owner := flags.String("owner", "", "")
+	due := flags.String("due", "", "")
+	path := flags.String("path", "", "")
+	all := flags.Bool("all", false, "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	dueTime, err := parseDue(*due, time.Now())
+	if err != nil {
+		return err
+	}
+
+	tasks := filterTasks(b.tree.Tasks(), *owner, dueTime, *path, *all)
+	sortTasks(tasks)
+
+	sb := &strings.Builder{}
+	for _, task := range tasks {
+		check, dueDate, taskOwner := " ", "-", "-"
+		if task.Done {
+			check = "x"
+		}
+
+		if !task.Due.IsZero() {
+			dueDate = task.Due.Format(time.DateOnly)
+		}
+
+		if task.Owner != "" {
+			taskOwner = "@" + task.Owner
+		}
+
+		fmt.Fprintf(sb, "[%s] %s\t%s\t%s\t%s\n", check, task.ID(), dueDate, taskOwner, task.Text)
+	}
+
+	log.Info("listed tasks")
+	wish.Print(s, sb)
+
+	return nil
+}
+
+func (b *Brain) handleTaskDone(s ssh.Session) error {
+	err := requireArgs(s, 1)
+	if err != nil {
+		return err
+	}
+
+	relPath, line, hash, err := parseTaskID(s.Command()[1])
+	if err != nil {
+		return err
+	}
+
+	err = b.updateNode(s, relPath, func(data []byte) ([]byte, error) {
+		return completeTask(data, line, hash)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("completed task %s:%d", relPath, line)
+	wish.Printf(s, "OK: completed %s:%d\n", relPath, line)
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package brain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tasksBrainfile = `---
+title: Tasks
+tags:
+- [ ] not a task
+---
+
+- [ ] Write the runbook @due(2026-11-01) @jed
+- [x] Ship it @sam
+  * [ ] Mail ops@example.com about @due(2026-10-20) the cut over
+
+` + "```" + `
+- [ ] in a code block
+` + "```" + `
+`
+
+func TestParseTasks(t *testing.T) {
+	tasks := parseTasks([]byte(tasksBrainfile))
+	expected := []Task{
+		{Line: 7, Text: "Write the runbook", Owner: "jed", Due: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{Line: 8, Text: "Ship it", Done: true, Owner: "sam"},
+		{Line: 9, Text: "Mail ops@example.com about the cut over", Due: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	if !slices.Equal(tasks, expected) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", expected, tasks)
+	}
+}
+
+func TestFilterTasks(t *testing.T) {
+	tasks := collectTasks([]*Node{
+		{Path: "ops.md", Tasks: parseTasks([]byte(tasksBrainfile))},
+		{Path: "journal", IsDir: true, Children: []*Node{
+			{Path: "journal/today.md", Tasks: []Task{{Line: 3, Text: "Review", Owner: "jed"}}},
+		}},
+	})
+
+	for _, test := range []struct {
+		owner    string
+		due      time.Time
+		path     string
+		all      bool
+		expected []string
+	}{
+		{expected: []string{"ops.md:9", "ops.md:7", "journal/today.md:3"}},
+		{all: true, expected: []string{"ops.md:9", "ops.md:7", "journal/today.md:3", "ops.md:8"}},
+		{owner: "@jed", expected: []string{"ops.md:7", "journal/today.md:3"}},
+		{due: time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC), expected: []string{"ops.md:9"}},
+		{path: "journal/", expected: []string{"journal/today.md:3"}},
+	} {
+		filtered := filterTasks(slices.Clone(tasks), test.owner, test.due, test.path, test.all)
+		sortTasks(filtered)
+		var ids []string
+		for _, task := range filtered {
+			ids = append(ids, fmt.Sprintf("%s:%d", task.Path, task.Line))
+		}
+
+		if !slices.Equal(ids, test.expected) {
+			t.Errorf("%+v: expected %v got %v", test, test.expected, ids)
+		}
+	}
+}
+
+func TestCompleteTask(t *testing.T) {
+	tasks := parseTasks([]byte(tasksBrainfile))
+	data, err := completeTask([]byte(tasksBrainfile), 9, tasks[2].hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks = parseTasks(data)
+	if !tasks[2].Done || tasks[2].Text != "Mail ops@example.com about the cut over" {
+		t.Errorf("expected task to be done got %+v", tasks[2])
+	}
+
+	for _, line := range []int{0, 4, 8, 13, 100} {
+		_, err = completeTask([]byte(tasksBrainfile), line, "00000000")
+		if !errors.Is(err, ErrNotTask) {
+			t.Errorf("line %d: expected not a task got %v", line, err)
+		}
+	}
+
+	_, err = completeTask([]byte(tasksBrainfile), 7, "00000000")
+	if !errors.Is(err, ErrTaskChanged) {
+		t.Errorf("expected changed task got %v", err)
+	}
+
+	// A task that has moved is found by its hash
+	data, err = completeTask([]byte(tasksBrainfile), 4, tasks[2].hash())
+	if err != nil || !parseTasks(data)[2].Done {
+		t.Errorf("expected moved task to be done got %v", err)
+	}
+}
+
+func TestParseTaskID(t *testing.T) {
+	path, line, hash, err := parseTaskID("ops/db:fail#1.md:12#0a1b2c3d")
+	if err != nil || path != "ops/db:fail#1.md" || line != 12 || hash != "0a1b2c3d" {
+		t.Errorf("expected ops/db:fail#1.md line 12 got %s %d %s %v", path, line, hash, err)
+	}
+
+	for _, id := range []string{"ops.md", "ops.md:12", "ops.md:x#0a1b2c3d", "ops.md:12#"} {
+		_, _, _, err = parseTaskID(id)
+		if err == nil {
+			t.Errorf("%s: expected error", id)
+		}
+	}
+}
+
+func TestTaskDoneSequential(t *testing.T) {
+	b := newTestBrain(t, map[string]string{"ops.md": "---\ntitle: Ops\n---\n\n- [ ] Write the runbook\n- [ ] Ship it\n"})
+	tasks := b.tree.Tasks()
+
+	// Ticking off tasks listed from the same tree, before it's rebuilt
+	for _, task := range tasks {
+		err := b.handleTaskDone(newTestSession("", TASK_DONE, task.ID()))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.config.ContentDir, "ops.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(string(data), "- [x]") != 2 {
+		t.Errorf("expected both tasks to be done got:\n%s", data)
+	}
+}
@@ -0,0 +1,252 @@
+package brain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultTemplate is the name of the built in BrainfileTemplate.
+const DefaultTemplate = "default"
+
+var (
+	ErrTemplateNotExist = errors.New("template does not exist")
+
+	// builtinTemplates can be overridden by templates of the same name.
+	builtinTemplates = map[string][]byte{
+		DefaultTemplate: BrainfileTemplate,
+		"daily":         dailyTemplate,
+		"weekly":        weeklyTemplate,
+	}
+)
+
+// templates returns the names of the available templates.
+func (b *Brain) templates() ([]string, error) {
+	var names []string
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+
+	if b.config.TemplatesDir != "" {
+		entries, err := os.ReadDir(b.config.TemplatesDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), ".md")
+			if ok && !entry.IsDir() && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	slices.Sort(names)
+	return names, nil
+}
+
+func (b *Brain) template(name string) ([]byte, error) {
+	if b.config.TemplatesDir != "" && filepath.IsLocal(name) && filepath.Base(name) == name {
+		data, err := os.ReadFile(filepath.Join(b.config.TemplatesDir, name+".md"))
+		if err == nil || !os.IsNotExist(err) {
+			return data, err
+		}
+	}
+
+	data, ok := builtinTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotExist, name)
+	}
+
+	return data, nil
+}
+
+// titleFromPath derives a title from a brainfile's file name.
+func titleFromPath(relPath string) string {
+	name := strings.TrimSuffix(filepath.Base(relPath), ".md")
+	return strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+}
+
+// parseVars parses name=value template variables, splitting on the first "="
+// so values may contain any character.
+func parseVars(sets []string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, set := range sets {
+		name, value, ok := strings.Cut(set, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid template variable %q, expected name=value", set)
+		}
+
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+// renderTemplate replaces the {{date}}, {{time}}, {{user}} and {{title}}
+// variables in a template, along with any others given in vars. Within the
+// frontmatter values are escaped so they can't change its structure.
+func renderTemplate(data []byte, user, title string, now time.Time, vars map[string]string) []byte {
+	values := maps.Clone(vars)
+	if values == nil {
+		values = map[string]string{}
+	}
+
+	values["date"] = now.Format(time.DateOnly)
+	values["time"] = now.Format("15:04")
+	values["user"] = user
+	values["title"] = title
+	var replacements []string
+	for name, value := range values {
+		replacements = append(replacements, "{{"+name+"}}", value)
+	}
+
+	text := string(data)
+	var frontmatter string
+	lines := strings.SplitAfter(text, "\n")
+	if strings.TrimSpace(lines[0]) == string(frontmatterDelimiter) {
+		offset := len(lines[0])
+		for _, line := range lines[1:] {
+			offset += len(line)
+			if strings.TrimSpace(line) == string(frontmatterDelimiter) {
+				frontmatter, text = renderFrontmatter(text[:offset], values), text[offset:]
+				break
+			}
+		}
+	}
+
+	return []byte(frontmatter + strings.NewReplacer(replacements...).Replace(text))
+}
+
+// renderFrontmatter replaces variables in frontmatter with their values
+// escaped for the YAML around them, within double or single quotes or quoted
+// if they can't be used as they are.
+func renderFrontmatter(text string, values map[string]string) string {
+	var out strings.Builder
+	// quote is the quote the current scalar is in, which can only open at the
+	// start of a value
+	var quote, prev byte
+	for i := 0; i < len(text); i++ {
+		if end := strings.Index(text[i:], "}}"); strings.HasPrefix(text[i:], "{{") && end != -1 {
+			if value, ok := values[text[i+2:i+end]]; ok {
+				out.WriteString(escapeYAML(value, quote))
+				i += end + 1
+				prev = '}'
+				continue
+			}
+		}
+
+		c := text[i]
+		switch {
+		case c == '\n':
+			quote, prev = 0, 0
+		case quote == 0 && (c == '"' || c == '\'') && strings.IndexByte(":-[{,", prev) != -1:
+			quote = c
+		case quote == '"' && c == '\\' && i+1 < len(text):
+			out.WriteByte(c)
+			i++
+			c = text[i]
+		case quote == '\'' && c == '\'' && strings.HasPrefix(text[i+1:], "'"):
+			out.WriteByte(c)
+			i++
+		case c == quote:
+			quote = 0
+		}
+
+		out.WriteByte(c)
+		if c != ' ' && c != '\n' {
+			prev = c
+		}
+	}
+
+	return out.String()
+}
+
+// escapeYAML escapes value for use within quote, or as a bare value if quote
+// is 0. Go's escapes are a subset of those in YAML's double quoted scalars.
+func escapeYAML(value string, quote byte) string {
+	switch quote {
+	case '"':
+		quoted := strconv.Quote(value)
+		return quoted[1 : len(quoted)-1]
+	case '\'':
+		// Single quoted scalars fold line breaks
+		return strings.ReplaceAll(strings.ReplaceAll(value, "'", "''"), "\n", " ")
+	}
+
+	var parsed yaml.MapSlice
+	err := yaml.Unmarshal([]byte("value: "+value), &parsed)
+	if err != nil || len(parsed) != 1 || strings.ContainsAny(value, "\n#") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
+
+func (b *Brain) handleTemplates(s ssh.Session) error {
+	names, err := b.templates()
+	if err != nil {
+		return err
+	}
+
+	log.Info("listed templates")
+	wish.Println(s, strings.Join(names, "\n"))
+
+	return nil
+}
+
+// handleTemplate renders the named template for a new brainfile at the
+// optional path, titled from the path unless --title is given. Further
+// variables are set with --set name=value.
+func (b *Brain) handleTemplate(s ssh.Session) error {
+	flags := pflag.NewFlagSet(TEMPLATE, pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	title := flags.String("title", "", "")
+	// Not StringToString, which parses values containing "=" as CSV
+	sets := flags.StringArray("set", nil, "")
+	err := flags.Parse(s.Command()[1:])
+	if err != nil {
+		return err
+	}
+
+	vars, err := parseVars(*sets)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 {
+		return fmt.Errorf("%s requires 1 argument(s)", TEMPLATE)
+	}
+
+	data, err := b.template(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *title == "" && flags.NArg() > 1 {
+		*title = titleFromPath(flags.Arg(1))
+	}
+
+	user := s.User()
+	if user == "" {
+		user = fingerprint(s.PublicKey())
+	}
+
+	log.Infof("sent template %s", flags.Arg(0))
+	wish.Print(s, string(renderTemplate(data, user, *title, time.Now(), vars)))
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package brain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedrw/brain/internal/config"
+)
+
+func TestTemplates(t *testing.T) {
+	dir := t.TempDir()
+	b := &Brain{config: config.Config{Server: config.Server{TemplatesDir: dir}}}
+	for name, content := range map[string]string{
+		"runbook.md": "---\ntitle: \"{{title}}\"\nowner: {{user}}\nreviewed: {{date}}\n---\n{{previous}}\n",
+		"adr.md":     "---\ntitle: \"ADR: {{title}}\"\n---\n",
+		"notes.txt":  "",
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := b.templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(names, []string{"adr", "daily", DefaultTemplate, "runbook", "weekly"}) {
+		t.Errorf("expected built in templates with adr and runbook got %v", names)
+	}
+
+	data, err := b.template("runbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	rendered := renderTemplate(data, "jed", titleFromPath("ops/db-fail_over.md"), now, map[string]string{"previous": "[before](before.md)"})
+	expected := "---\ntitle: \"db fail over\"\nowner: jed\nreviewed: 2026-10-18\n---\n[before](before.md)\n"
+	if string(rendered) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, rendered)
+	}
+
+	for _, name := range []string{"missing", "../runbook", "notes.txt"} {
+		_, err = b.template(name)
+		if !errors.Is(err, ErrTemplateNotExist) {
+			t.Errorf("%s: expected template not to exist got %v", name, err)
+		}
+	}
+}
+
+func TestHandleTemplateVars(t *testing.T) {
+	b := &Brain{config: config.Config{Server: config.Server{TemplatesDir: t.TempDir()}}}
+	err := os.WriteFile(filepath.Join(b.config.TemplatesDir, "day.md"), []byte("{{previous}}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := `[a=b, "c"](journal/2026/10/17.md)`
+	s := newTestSession("", TEMPLATE, "--set", "previous="+previous, "day")
+	err = b.handleTemplate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.stdout.String() != previous+"\n" {
+		t.Errorf("expected %q got %q", previous+"\n", s.stdout.String())
+	}
+
+	_, err = parseVars([]string{"previous"})
+	if err == nil {
+		t.Error("expected a variable without a value to be invalid")
+	}
+}
+
+func TestRenderTemplateEscapes(t *testing.T) {
+	title := "Say \"hi\": it's\nowner: someone else"
+	data := []byte("---\ntitle: \"{{title}}\"\nalias: '{{title}}'\nsummary: {{title}}\nowner: {{user}}\nreviewed: {{date}}\n---\n# {{title}}\n")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	rendered := renderTemplate(data, "jed", title, now, nil)
+
+	frontmatter, body, err := SplitFrontmatter(rendered)
+	if err != nil {
+		t.Fatalf("expected valid frontmatter got %s:\n%s", err, rendered)
+	}
+
+	for _, key := range []string{"title", "summary"} {
+		value, _ := GetFrontmatter(frontmatter, key)
+		if value != title {
+			t.Errorf("expected %s %q got %q", key, title, value)
+		}
+	}
+
+	value, _ := GetFrontmatter(frontmatter, "alias")
+	if value != strings.ReplaceAll(title, "\n", " ") {
+		t.Errorf("expected alias %q got %q", strings.ReplaceAll(title, "\n", " "), value)
+	}
+
+	value, _ = GetFrontmatter(frontmatter, "owner")
+	if value != "jed" || len(frontmatter) != 5 {
+		t.Errorf("expected the title not to add or replace keys got %v", frontmatter)
+	}
+
+	// The body is markdown, not YAML
+	if string(body) != "# "+title+"\n" {
+		t.Errorf("expected the title in the body as it is got %q", body)
+	}
+}
@@ -0,0 +1,75 @@
+package brain
+
+import (
+	"time"
+)
+
+const (
+	CreatedField   = "created"
+	UpdatedField   = "updated"
+	CreatedByField = "created_by"
+	UpdatedByField = "updated_by"
+)
+
+// parseMetaTime returns the time of a date frontmatter value, or the zero
+// time if it is not one.
+func parseMetaTime(value any) time.Time {
+	switch value := value.(type) {
+	case time.Time:
+		return value
+	case string:
+		for _, layout := range dateLayouts {
+			t, err := time.Parse(layout, value)
+			if err == nil {
+				return t
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// stamp records in the frontmatter of data that author saved it at now. New
+// brainfiles are also marked as created then, existing ones keep when and by
+// whom they were created from their current data. Created fields in data are
+// always replaced so clients can't set them. Only the lines of these fields
+// are changed, the rest of the frontmatter is kept as written.
+func stamp(data, existing []byte, author string, now time.Time) ([]byte, error) {
+	timestamp := now.UTC().Format(time.RFC3339)
+	for _, key := range []string{CreatedField, CreatedByField} {
+		if existing != nil {
+			// Existing brainfiles saved without timestamps have no creation
+			// to keep, so the field is removed
+			data = setFrontmatterText(data, key, frontmatterText(existing, key))
+			continue
+		}
+
+		value := timestamp
+		if key == CreatedByField {
+			value = author
+		}
+
+		var err error
+		data, err = setFrontmatterValue(data, key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := setFrontmatterValue(data, UpdatedField, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return setFrontmatterValue(data, UpdatedByField, author)
+}
+
+// LastUpdated returns when the node was last updated, falling back to its
+// modification time for brainfiles saved without timestamps.
+func (n *Node) LastUpdated() time.Time {
+	if !n.Updated.IsZero() {
+		return n.Updated
+	}
+
+	return n.ModTime
+}
@@ -0,0 +1,146 @@
+package brain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStamp(t *testing.T) {
+	created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	updated := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	data, err := stamp([]byte("---\ntitle: Deploy\n---\nbody\n"), nil, "SHA256:a", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := NewNodeFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !node.Created.Equal(created) || !node.Updated.Equal(created) || node.CreatedBy != "SHA256:a" || node.UpdatedBy != "SHA256:a" {
+		t.Errorf("expected new node created and updated by SHA256:a at %s got %+v", created, node)
+	}
+
+	// Saving without the created fields, as when written by another tool,
+	// keeps them from the existing data
+	data, err = stamp([]byte("---\ntitle: Deploy v2\n---\nbody\n"), data, "SHA256:b", updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err = NewNodeFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !node.Created.Equal(created) || node.CreatedBy != "SHA256:a" {
+		t.Errorf("expected created to be kept got %s by %s", node.Created, node.CreatedBy)
+	}
+
+	if !node.Updated.Equal(updated) || node.UpdatedBy != "SHA256:b" || node.Title != "Deploy v2" {
+		t.Errorf("expected updated by SHA256:b at %s got %+v", updated, node)
+	}
+
+	if !strings.HasSuffix(string(data), "---\nbody\n") {
+		t.Errorf("expected body to be kept got:\n%s", data)
+	}
+}
+
+func TestStampForgedCreated(t *testing.T) {
+	created := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	updated := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	forged := "---\ntitle: Deploy\ncreated: 2020-01-01T00:00:00Z\ncreated_by: SHA256:forged\n---\n"
+
+	data, err := stamp([]byte(forged), nil, "SHA256:a", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := NewNodeFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !node.Created.Equal(created) || node.CreatedBy != "SHA256:a" {
+		t.Errorf("expected a new brainfile to be created by SHA256:a at %s got %s by %s", created, node.Created, node.CreatedBy)
+	}
+
+	data, err = stamp([]byte(forged), data, "SHA256:b", updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err = NewNodeFromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !node.Created.Equal(created) || node.CreatedBy != "SHA256:a" {
+		t.Errorf("expected created to be kept from the existing brainfile got %s by %s", node.Created, node.CreatedBy)
+	}
+
+	// Existing brainfiles without timestamps have nothing to keep
+	data, err = stamp([]byte(forged), []byte("---\ntitle: Deploy\n---\n"), "SHA256:b", updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), "created") {
+		t.Errorf("expected forged created fields to be removed got:\n%s", data)
+	}
+}
+
+func TestStampKeepsFrontmatter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	original := "---\n# Owned by ops\ntitle: 'Deploy'\ntags:\n  - ops\nupdated: 2026-10-01T08:00:00Z\nupdated_by: SHA256:a\nstatus: \"draft\"\n---\nbody\n"
+	data, err := stamp([]byte(original), []byte(original), "SHA256:b", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "---\n# Owned by ops\ntitle: 'Deploy'\ntags:\n  - ops\nupdated: \"2026-10-18T12:00:00Z\"\nupdated_by: SHA256:b\nstatus: \"draft\"\n---\nbody\n"
+	if string(data) != expected {
+		t.Errorf("expected only the updated fields to change got:\n%s", data)
+	}
+}
+
+func TestSortNodes(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	nodes := []*Node{
+		{Path: "a.md", Title: "C", Updated: now.Add(-2 * day), Created: now.Add(-3 * day)},
+		{Path: "b.md", Title: "A", ModTime: now, Created: now.Add(-9 * day)},
+		{Path: "c.md", Title: "B", Updated: now.Add(-day), Created: now.Add(-day)},
+	}
+
+	tests := map[string]string{
+		"":        "a.md b.md c.md",
+		"title":   "b.md c.md a.md",
+		"created": "c.md a.md b.md",
+		"updated": "b.md c.md a.md",
+	}
+
+	for by, expected := range tests {
+		sorted := append([]*Node{}, nodes...)
+		err := sortNodes(sorted, by)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var paths []string
+		for _, node := range sorted {
+			paths = append(paths, node.Path)
+		}
+
+		if strings.Join(paths, " ") != expected {
+			t.Errorf("%q: expected %s got %s", by, expected, strings.Join(paths, " "))
+		}
+	}
+
+	if sortNodes(nodes, "size") == nil {
+		t.Error("expected error for unknown sort")
+	}
+}
@@ -13,6 +13,7 @@ import (
 type Tree struct {
 	mu    sync.RWMutex
 	nodes []*Node
+	tasks []Task
 }
 
 func (t *Tree) Find(path string) (*Node, error) {
@@ -50,26 +51,75 @@ func (t *Tree) Find(path string) (*Node, error) {
 	return found, nil
 }
 
-func (t *Tree) getNodes(baseDir, currentPath string) ([]*Node, error) {
+// buildErrors records problems with individual paths found while building
+// the tree so that one bad entry does not prevent the rest being served.
+type buildErrors struct {
+	paths   map[string]string
+	invalid map[string]string
+}
+
+func newBuildErrors() *buildErrors {
+	return &buildErrors{
+		paths:   map[string]string{},
+		invalid: map[string]string{},
+	}
+}
+
+// Paths returns the paths of all brainfiles in the tree.
+func (t *Tree) Paths() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var paths []string
+	var walk func(nodes []*Node)
+	walk = func(nodes []*Node) {
+		for _, node := range nodes {
+			if node.IsDir {
+				walk(node.Children)
+			} else {
+				paths = append(paths, node.Path)
+			}
+		}
+	}
+	walk(t.nodes)
+
+	return paths
+}
+
+func (t *Tree) getNodes(baseDir, currentPath string, errs *buildErrors) ([]*Node, error) {
 	entries, err := os.ReadDir(filepath.Join(baseDir, currentPath))
 	if err != nil {
 		return nil, err
 	}
 
+	names := map[string]bool{}
+	for _, entry := range entries {
+		names[entry.Name()] = entry.IsDir()
+	}
+
 	var nodes []*Node
 	for _, entry := range entries {
 		relPath := filepath.Join(currentPath, entry.Name())
 		var node Node
-		if entry.IsDir() {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), AssetsSuffix) {
+			// Attachments are listed with their brainfile
+			if _, ok := names[strings.TrimSuffix(entry.Name(), AssetsSuffix)+".md"]; !ok {
+				log.Warn("attachments directory without brainfile", "path", relPath)
+			}
+
+			continue
+		} else if entry.IsDir() {
 			node = Node{
 				Title: strings.TrimSuffix(entry.Name(), ".md"),
 				Path:  relPath,
 				IsDir: entry.IsDir(),
 			}
 
-			children, err := t.getNodes(baseDir, relPath)
+			children, err := t.getNodes(baseDir, relPath, errs)
 			if err != nil {
-				return nil, err
+				log.Warn("could not read directory", "path", relPath, "err", err)
+				errs.paths[relPath] = err.Error()
+				continue
 			}
 
 			node.Children = children
@@ -79,13 +129,28 @@ func (t *Tree) getNodes(baseDir, currentPath string) ([]*Node, error) {
 			if err != nil {
 				if errors.Is(err, ErrInvalidBrainNode) {
 					log.Warn("invalid brain node", "path", relPath)
+					errs.invalid[relPath] = err.Error()
 					continue
 				}
 
-				return nil, err
+				log.Warn("could not read brainfile", "path", relPath, "err", err)
+				errs.paths[relPath] = err.Error()
+				continue
 			}
 
 			node.Path = relPath
+			info, err := entry.Info()
+			if err == nil {
+				node.ModTime = info.ModTime().UTC()
+			}
+
+			if names[filepath.Base(AssetsDir(relPath))] {
+				node.Attachments, err = listAttachments(baseDir, AssetsDir(relPath))
+				if err != nil {
+					log.Warn("could not read attachments", "path", relPath, "err", err)
+					errs.paths[AssetsDir(relPath)] = err.Error()
+				}
+			}
 		}
 
 		nodes = append(nodes, &node)
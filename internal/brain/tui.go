@@ -0,0 +1,72 @@
+package brain
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/ssh"
+	bm "github.com/charmbracelet/wish/bubbletea"
+	"github.com/jedrw/brain/internal/tui"
+)
+
+// sessionStore exposes the brain to the TUI, attributing changes to the
+// session's user.
+type sessionStore struct {
+	b *Brain
+	s ssh.Session
+}
+
+func (st sessionStore) Paths() []string {
+	return st.b.tree.Paths()
+}
+
+func (st sessionStore) Body(path string) ([]byte, error) {
+	node, err := st.b.tree.Find(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, body, err := SplitFrontmatter(node.Raw)
+	return body, err
+}
+
+func (st sessionStore) Move(from, to string) error {
+	err := st.b.moveNode(st.s, from, to)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("moved %s to %s", from, to)
+	return nil
+}
+
+func (st sessionStore) Delete(path string) error {
+	err := st.b.deleteNode(st.s, path)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("deleted %s", path)
+	return nil
+}
+
+// tuiHandler serves the TUI browser to interactive sessions.
+func (b *Brain) tuiHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+	if len(s.Command()) > 0 {
+		return nil, nil
+	}
+
+	pty, _, ok := s.Pty()
+	if !ok {
+		return nil, nil
+	}
+
+	// The ssh handler has already rejected sessions for brains the key may
+	// not use
+	target, err := b.sessionBrain(s)
+	if err != nil {
+		return nil, nil
+	}
+
+	model := tui.New(sessionStore{b: target, s: s}, bm.MakeRenderer(s), pty.Window.Width, pty.Window.Height)
+	return model, []tea.ProgramOption{tea.WithAltScreen()}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jedrw/brain/internal/archive"
+	"github.com/jedrw/brain/internal/brain"
+)
+
+// run runs command, returning an error if the server reported one.
+func (c *sshClient) run(command string, in io.Reader, args ...string) (string, error) {
+	out, err := c.RunCommand(command, in, args...)
+	if err != nil {
+		return out, err
+	}
+
+	if msg, ok := strings.CutPrefix(out, "ERROR: "); ok {
+		return out, errors.New(strings.TrimSpace(msg))
+	}
+
+	return out, nil
+}
+
+func (c *sshClient) Manifest(root string) ([]brain.ManifestEntry, error) {
+	var args []string
+	if root != "" {
+		args = append(args, root)
+	}
+
+	out, err := c.run(brain.MANIFEST, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []brain.ManifestEntry
+	err = json.Unmarshal([]byte(out), &entries)
+	return entries, err
+}
+
+func (c *sshClient) Get(path string) ([]byte, error) {
+	out, err := c.run(brain.SHOW, nil, path)
+	return []byte(out), err
+}
+
+// Put saves data as the brainfile or attachment at path.
+func (c *sshClient) Put(path string, data []byte) error {
+	if note, name, ok := brain.AttachmentOf(path); ok {
+		_, err := c.run(brain.ATTACH, bytes.NewReader(data), note, name)
+		return err
+	}
+
+	_, err := c.run(brain.NEW, bytes.NewReader(data), path)
+	return err
+}
+
+func (c *sshClient) Delete(path string) error {
+	_, err := c.run(brain.DELETE, nil, path)
+	return err
+}
+
+// Export streams an archive or HTML document of the brain, or the subtree at
+// root, to w.
+func (c *sshClient) Export(format, title, root string, w io.Writer) error {
+	args := []string{"--format", format}
+	if title != "" {
+		args = append(args, "--title", title)
+	}
+
+	if root != "" {
+		args = append(args, root)
+	}
+
+	return c.Stream(brain.EXPORT, nil, w, args...)
+}
+
+// ImportArchive restores an archive created by Export.
+func (c *sshClient) ImportArchive(format archive.Format, r io.Reader) (string, error) {
+	return c.run(brain.IMPORT_ARCHIVE, r, "--format", string(format))
+}
+
+func (c *sshClient) Templates() ([]string, error) {
+	out, err := c.run(brain.TEMPLATES, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(out), nil
+}
+
+// Template renders the named template for a new brainfile at path, setting
+// any extra template variables in vars.
+func (c *sshClient) Template(name, path, title string, vars map[string]string) ([]byte, error) {
+	args := []string{name, path}
+	if title != "" {
+		args = append(args, "--title", title)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		args = append(args, "--set", name+"="+vars[name])
+	}
+
+	out, err := c.run(brain.TEMPLATE, nil, args...)
+	return []byte(out), err
+}
+
+// Append appends data to the brainfile at path, or to the section under the
+// heading with the given anchor.
+func (c *sshClient) Append(path, heading string, data []byte) (string, error) {
+	args := []string{path}
+	if heading != "" {
+		args = append(args, "--heading", heading)
+	}
+
+	return c.run(brain.APPEND, bytes.NewReader(data), args...)
+}
+
+// Patch applies a unified diff to the brainfile at path.
+func (c *sshClient) Patch(path string, diff []byte) (string, error) {
+	return c.run(brain.PATCH, bytes.NewReader(diff), path)
+}
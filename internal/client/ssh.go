@@ -1,19 +1,26 @@
 package client
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"net"
 	"os"
+	"os/user"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/jedrw/brain/internal/brain"
 	"github.com/jedrw/brain/internal/config"
 	"golang.org/x/crypto/ssh"
 )
 
 type sshClient struct {
 	con *ssh.Client
+	// brain is the named brain commands run against, the default if empty.
+	brain string
 }
 
 func publicKeyAuth(keyPath string) (ssh.AuthMethod, error) {
@@ -34,6 +41,17 @@ func publicKeyAuth(keyPath string) (ssh.AuthMethod, error) {
 	return ssh.PublicKeys(signer), nil
 }
 
+// currentUser returns the local user name, sent to the server to identify
+// the user in templates.
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+
+	return u.Username
+}
+
 func NewSSHClient(config config.Config) (*sshClient, error) {
 	authMethod, err := publicKeyAuth(config.KeyPath)
 	if err != nil {
@@ -41,6 +59,7 @@ func NewSSHClient(config config.Config) (*sshClient, error) {
 	}
 
 	conConfig := &ssh.ClientConfig{
+		User: currentUser(),
 		Auth: []ssh.AuthMethod{authMethod},
 		// TODO: use ssh.FixedHostKey, read Hostkey from config
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
@@ -53,16 +72,56 @@ func NewSSHClient(config config.Config) (*sshClient, error) {
 	}
 
 	return &sshClient{
-		con: con,
+		con:   con,
+		brain: config.Brain,
 	}, nil
 }
 
+// newSession opens a session addressed to the client's brain.
+func (c *sshClient) newSession() (*ssh.Session, error) {
+	sess, err := c.con.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.brain != "" {
+		err = sess.Setenv(brain.BrainEnv, c.brain)
+		if err != nil {
+			sess.Close()
+			return nil, err
+		}
+	}
+
+	return sess, nil
+}
+
 func (c *sshClient) Close() error {
 	return c.con.Close()
 }
 
+// quote quotes arg for the server's shell-style command parsing if required.
+func quote(arg string) string {
+	if arg != "" && !strings.ContainsFunc(arg, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`'"\$`+"`", r)
+	}) {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+}
+
+// commandLine joins command and its quoted args.
+func commandLine(command string, args []string) string {
+	quoted := []string{command}
+	for _, arg := range args {
+		quoted = append(quoted, quote(arg))
+	}
+
+	return strings.Join(quoted, " ")
+}
+
 func (c *sshClient) RunCommand(command string, in io.Reader, args ...string) (string, error) {
-	sess, err := c.con.NewSession()
+	sess, err := c.newSession()
 	if err != nil {
 		return "", err
 	}
@@ -71,10 +130,38 @@ func (c *sshClient) RunCommand(command string, in io.Reader, args ...string) (st
 		sess.Stdin = in
 	}
 
-	out, err := sess.Output(strings.Join(append([]string{command}, args...), " "))
+	out, err := sess.Output(commandLine(command, args))
 	if err != nil {
 		return string(out), err
 	}
 
 	return string(out), nil
 }
+
+// Stream runs a command whose output is streamed to out rather than buffered,
+// such as an archive. Errors are reported by the server on stderr.
+func (c *sshClient) Stream(command string, in io.Reader, out io.Writer, args ...string) error {
+	sess, err := c.newSession()
+	if err != nil {
+		return err
+	}
+
+	if in != nil {
+		sess.Stdin = in
+	}
+
+	var stderr bytes.Buffer
+	sess.Stdout = out
+	sess.Stderr = &stderr
+	err = sess.Run(commandLine(command, args))
+	if err != nil {
+		msg := strings.TrimSpace(strings.TrimPrefix(stderr.String(), "ERROR: "))
+		if msg != "" {
+			return errors.New(msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
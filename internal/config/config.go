@@ -2,6 +2,7 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,6 +20,12 @@ const (
 	HostKeyPathFlag    = "host-key-path"
 	AuthorizedKeysFlag = "authorized-keys"
 	KeyPathFlag        = "key-path"
+	AdminPortFlag      = "admin-port"
+	AuditLogPathFlag   = "audit-log-path"
+	GitDirFlag         = "git-dir"
+	TemplatesDirFlag   = "templates-dir"
+	BrainFlag          = "brain"
+	ProfileFlag        = "profile"
 
 	// Defaults
 	ContentDirDefault     = "./docs"
@@ -26,21 +33,100 @@ const (
 	AuthorizedKeysDefault = ""
 	AddressDefault        = ""
 	PortDefault           = 2222
+	AdminPortDefault      = 0
+	AuditLogPathDefault   = "./audit.log"
 	UpdateTaskDefault     = "mkdocs build"
+	HookRetriesDefault    = 3
+	DailyPatternDefault   = "journal/{{year}}/{{month}}/{{date}}.md"
+	WeeklyPatternDefault  = "journal/{{year}}/{{year}}-W{{week}}.md"
 )
 
 var (
 	KeyPathDefault = filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519")
 )
 
+// Config is the configuration shared by the client and server, both of
+// which use Port.
 type Config struct {
-	Address        string   `yaml:"address"`
-	Port           int      `yaml:"port"`
+	Port   int `yaml:"port"`
+	Client `yaml:",inline"`
+	Server `yaml:",inline"`
+	// Profile is the profile used when --profile is not set.
+	Profile  string             `yaml:"profile"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Client settings configure the client's connection to a brain server.
+type Client struct {
+	Address       string `yaml:"address"`
+	Brain         string `yaml:"brain"`
+	KeyPath       string `yaml:"keyPath"`
+	DailyPattern  string `yaml:"dailyPattern"`
+	WeeklyPattern string `yaml:"weeklyPattern"`
+}
+
+// Profile is a named set of client settings targeting one server, set
+// fields overriding the top level settings.
+type Profile struct {
+	Port   int `yaml:"port"`
+	Client `yaml:",inline"`
+}
+
+// Server settings are only used by brain serve.
+type Server struct {
+	// AdminPort serves /healthz, /readyz and /metrics, disabled if unset.
+	AdminPort      int      `yaml:"adminPort"`
 	HostKeyPath    string   `yaml:"hostKeyPath"`
 	AuthorizedKeys []string `yaml:"authorizedKeys"`
-	KeyPath        string   `yaml:"keyPath"`
 	ContentDir     string   `yaml:"contentDir"`
 	UpdateTasks    []string `yaml:"updateTasks"`
+	AuditLogPath   string   `yaml:"auditLogPath"`
+	GitDir         string   `yaml:"gitDir"`
+	TemplatesDir   string   `yaml:"templatesDir"`
+	Hooks          []Hook   `yaml:"hooks"`
+	Schema         []Schema `yaml:"schema"`
+	Brains         []Named  `yaml:"brains"`
+}
+
+// Named declares a brain served alongside the default brain with its own
+// content, git repository, update tasks, hooks and access list. It shares the
+// server's schema and templates, but not its update tasks or hooks, so events
+// from a private brain aren't sent to the default brain's hooks.
+type Named struct {
+	Name        string   `yaml:"name"`
+	ContentDir  string   `yaml:"contentDir"`
+	GitDir      string   `yaml:"gitDir"`
+	UpdateTasks []string `yaml:"updateTasks"`
+	Hooks       []Hook   `yaml:"hooks"`
+	// AuthorizedKeys that may use the brain, the server's authorized keys if
+	// empty.
+	AuthorizedKeys []string `yaml:"authorizedKeys"`
+}
+
+type Hook struct {
+	// Events the hook fires on, all events if empty.
+	Events  []string `yaml:"events"`
+	Command string   `yaml:"command"`
+	URL     string   `yaml:"url"`
+	Secret  string   `yaml:"secret"`
+	Retries int      `yaml:"retries"`
+}
+
+// Schema declares the frontmatter brainfiles under Paths must have.
+type Schema struct {
+	// Paths the schema applies to as directories or globs, all brainfiles if
+	// empty.
+	Paths  []string         `yaml:"paths"`
+	Fields map[string]Field `yaml:"fields"`
+}
+
+type Field struct {
+	// Type is one of string, number, bool, date or list.
+	Type     string   `yaml:"type"`
+	Required bool     `yaml:"required"`
+	Enum     []string `yaml:"enum"`
+	// Format is the Go time layout of date fields.
+	Format string `yaml:"format"`
 }
 
 func isFlagSet(name string) bool {
@@ -54,19 +140,98 @@ func isFlagSet(name string) bool {
 	return found
 }
 
+func setHookDefaults(hooks []Hook) {
+	for i := range hooks {
+		if hooks[i].Retries == 0 {
+			hooks[i].Retries = HookRetriesDefault
+		}
+	}
+}
+
+// applyProfile overrides the client settings with those of the profile set by
+// --profile, or the default profile.
+func (c *Config) applyProfile(flags *pflag.FlagSet) error {
+	name := c.Profile
+	if flags.Changed(ProfileFlag) {
+		name, _ = flags.GetString(ProfileFlag)
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	if profile.Port != 0 {
+		c.Port = profile.Port
+	}
+
+	if profile.Address != "" {
+		c.Address = profile.Address
+	}
+
+	if profile.Brain != "" {
+		c.Brain = profile.Brain
+	}
+
+	if profile.KeyPath != "" {
+		c.KeyPath = profile.KeyPath
+	}
+
+	if profile.DailyPattern != "" {
+		c.DailyPattern = profile.DailyPattern
+	}
+
+	if profile.WeeklyPattern != "" {
+		c.WeeklyPattern = profile.WeeklyPattern
+	}
+
+	return nil
+}
+
 func (c *Config) setOverrides(flags *pflag.FlagSet) {
 	if c.Address == "" || isFlagSet(AddressFlag) {
 		c.Address, _ = flags.GetString(AddressFlag)
 	}
 
+	// The brain may be addressed as host:/name, with --brain taking precedence
+	host, name, ok := strings.Cut(c.Address, ":/")
+	if ok {
+		c.Address = host
+		c.Brain = name
+	}
+
+	if flags.Changed(BrainFlag) {
+		c.Brain, _ = flags.GetString(BrainFlag)
+	}
+
 	if c.Port == 0 || isFlagSet(PortFlag) {
 		c.Port, _ = flags.GetInt(PortFlag)
 	}
 
+	if c.AdminPort == 0 || isFlagSet(AdminPortFlag) {
+		c.AdminPort, _ = flags.GetInt(AdminPortFlag)
+	}
+
 	if c.ContentDir == "" || isFlagSet(ContentDirFlag) {
 		c.ContentDir, _ = flags.GetString(ContentDirFlag)
 	}
 
+	if c.AuditLogPath == "" || isFlagSet(AuditLogPathFlag) {
+		c.AuditLogPath, _ = flags.GetString(AuditLogPathFlag)
+	}
+
+	if c.GitDir == "" || isFlagSet(GitDirFlag) {
+		c.GitDir, _ = flags.GetString(GitDirFlag)
+	}
+
+	if c.TemplatesDir == "" || isFlagSet(TemplatesDirFlag) {
+		c.TemplatesDir, _ = flags.GetString(TemplatesDirFlag)
+	}
+
 	if c.HostKeyPath == "" || isFlagSet(HostKeyPathFlag) {
 		c.HostKeyPath, _ = flags.GetString(HostKeyPathFlag)
 	}
@@ -75,6 +240,19 @@ func (c *Config) setOverrides(flags *pflag.FlagSet) {
 		c.UpdateTasks = append(c.UpdateTasks, UpdateTaskDefault)
 	}
 
+	if c.DailyPattern == "" {
+		c.DailyPattern = DailyPatternDefault
+	}
+
+	if c.WeeklyPattern == "" {
+		c.WeeklyPattern = WeeklyPatternDefault
+	}
+
+	setHookDefaults(c.Hooks)
+	for i := range c.Brains {
+		setHookDefaults(c.Brains[i].Hooks)
+	}
+
 	if len(c.AuthorizedKeys) == 0 || isFlagSet(AuthorizedKeysFlag) {
 		keysString, _ := flags.GetString(AuthorizedKeysFlag)
 		keys := strings.SplitSeq(keysString, ",")
@@ -88,7 +266,7 @@ func (c *Config) setOverrides(flags *pflag.FlagSet) {
 	}
 }
 
-func New(configPath string, flags *pflag.FlagSet) (Config, error) {
+func load(configPath string) (Config, error) {
 	if configPath == "" {
 		configPath = filepath.Join(xdg.ConfigHome, "brain", "config.yaml")
 	}
@@ -100,6 +278,29 @@ func New(configPath string, flags *pflag.FlagSet) (Config, error) {
 
 	var config Config
 	err = yaml.Unmarshal(configBytes, &config)
+	return config, err
+}
+
+// New loads the config for client commands, applying the selected profile.
+func New(configPath string, flags *pflag.FlagSet) (Config, error) {
+	config, err := load(configPath)
+	if err != nil {
+		return config, err
+	}
+
+	err = config.applyProfile(flags)
+	if err != nil {
+		return config, err
+	}
+
+	config.setOverrides(flags)
+
+	return config, nil
+}
+
+// NewServer loads the config for the server, which profiles do not apply to.
+func NewServer(configPath string, flags *pflag.FlagSet) (Config, error) {
+	config, err := load(configPath)
 	if err != nil {
 		return config, err
 	}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestBrainAddress(t *testing.T) {
+	tests := []struct {
+		config  Config
+		args    []string
+		address string
+		brain   string
+	}{
+		{Config{Client: Client{Address: "brain.example.com"}}, nil, "brain.example.com", ""},
+		{Config{Client: Client{Address: "brain.example.com", Brain: "team"}}, nil, "brain.example.com", "team"},
+		{Config{Client: Client{Address: "brain.example.com:/oncall", Brain: "team"}}, nil, "brain.example.com", "oncall"},
+		{Config{Client: Client{Address: "brain.example.com:/oncall"}}, []string{"--brain", "team"}, "brain.example.com", "team"},
+		{Config{}, []string{"-a", "brain.example.com:/oncall"}, "brain.example.com", "oncall"},
+	}
+
+	for _, test := range tests {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.StringP(AddressFlag, "a", "", "")
+		flags.String(BrainFlag, "", "")
+		err := flags.Parse(test.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.config.setOverrides(flags)
+		if test.config.Address != test.address || test.config.Brain != test.brain {
+			t.Errorf("%v: expected %s and %q got %s and %q", test.args, test.address, test.brain, test.config.Address, test.config.Brain)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configPath, []byte(`
+port: 2222
+keyPath: ~/.ssh/id_ed25519
+contentDir: /brain/docs
+profile: work
+profiles:
+  work:
+    address: brain.example.com:/team
+  dev:
+    address: dev.brain.example.com
+    port: 2223
+    keyPath: ~/.ssh/dev
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    []string
+		address string
+		port    int
+		keyPath string
+		brain   string
+	}{
+		{nil, "brain.example.com", 2222, "~/.ssh/id_ed25519", "team"},
+		{[]string{"--profile", "dev"}, "dev.brain.example.com", 2223, "~/.ssh/dev", ""},
+	}
+
+	for _, test := range tests {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.String(ProfileFlag, "", "")
+		err := flags.Parse(test.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := New(configPath, flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if config.Address != test.address || config.Port != test.port || config.KeyPath != test.keyPath || config.Brain != test.brain {
+			t.Errorf("%v: expected %s:%d %s %q got %s:%d %s %q", test.args, test.address, test.port, test.keyPath, test.brain, config.Address, config.Port, config.KeyPath, config.Brain)
+		}
+
+		if config.ContentDir != "/brain/docs" {
+			t.Errorf("%v: expected server settings to be kept got %q", test.args, config.ContentDir)
+		}
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String(ProfileFlag, "", "")
+	flags.Parse([]string{"--profile", "home"})
+	_, err = New(configPath, flags)
+	if err == nil {
+		t.Error("expected error for unknown profile")
+	}
+
+	// The server ignores profiles, even the default
+	flags = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String(ProfileFlag, "", "")
+	flags.Parse([]string{"--profile", "dev"})
+	config, err := NewServer(configPath, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Port != 2222 || config.Address != "" {
+		t.Errorf("expected server to ignore profiles got %s:%d", config.Address, config.Port)
+	}
+}
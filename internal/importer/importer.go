@@ -0,0 +1,185 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jedrw/brain/internal/brain"
+)
+
+// Uploader is the brain brainfiles are imported into.
+type Uploader interface {
+	Put(path string, data []byte) error
+}
+
+type Options struct {
+	// Prefix is the path in the brain files are imported beneath
+	Prefix string
+	// Obsidian converts files from Obsidian vault conventions
+	Obsidian bool
+	// DryRun validates and reports without uploading
+	DryRun bool
+}
+
+type Result struct {
+	Imported []string
+	// Attached lists the files embedded in imported notes that were
+	// uploaded as their attachments
+	Attached []string
+	Failed   map[string]error
+}
+
+// Title returns the text of the first level one heading in body, or a title
+// derived from the file name if there isn't one.
+func Title(body []byte, filePath string) string {
+	inFence := false
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			inFence = !inFence
+			continue
+		}
+
+		if heading, ok := strings.CutPrefix(line, "# "); ok && !inFence {
+			if heading = strings.TrimSpace(strings.TrimRight(heading, "#")); heading != "" {
+				return heading
+			}
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	return strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+}
+
+// Prepare adds title frontmatter to data if it is missing and validates the
+// result as a brainfile.
+func Prepare(filePath string, data []byte) ([]byte, error) {
+	frontmatter, body, err := brain.SplitFrontmatter(data)
+	if err != nil {
+		return nil, err
+	}
+
+	title, ok := brain.GetFrontmatter(frontmatter, "title")
+	if !ok || title == nil || title == "" {
+		frontmatter = brain.SetFrontmatter(frontmatter, "title", Title(body, filePath))
+		data, err = brain.JoinFrontmatter(frontmatter, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = brain.NewNodeFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// files returns the files under dir relative to it, skipping hidden files and
+// directories such as .obsidian.
+func files(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		paths = append(paths, rel)
+		return nil
+	})
+
+	return paths, err
+}
+
+// Import uploads every markdown file under dir into dest. Files that are not
+// valid brainfiles once prepared are reported and skipped. Files embedded in
+// Obsidian notes are uploaded as attachments of the notes after them.
+func Import(dir string, dest Uploader, opts Options, out io.Writer) (Result, error) {
+	result := Result{Failed: map[string]error{}}
+	all, err := files(dir)
+	if err != nil {
+		return result, err
+	}
+
+	var v *vault
+	if opts.Obsidian {
+		v = newVault(slices.Clone(all))
+	}
+
+	paths := slices.DeleteFunc(all, func(path string) bool { return filepath.Ext(path) != ".md" })
+	for i, path := range paths {
+		progress := fmt.Sprintf("[%d/%d] %s", i+1, len(paths), path)
+		var attachments []string
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		if err == nil && v != nil {
+			data, attachments, err = v.convertObsidian(path, data)
+		}
+
+		if err == nil {
+			data, err = Prepare(path, data)
+		}
+
+		if err == nil && !opts.DryRun {
+			err = dest.Put(filepath.Join(opts.Prefix, path), data)
+		}
+
+		if err != nil {
+			fmt.Fprintf(out, "%s: %s\n", progress, err)
+			result.Failed[path] = err
+			continue
+		}
+
+		fmt.Fprintln(out, progress)
+		result.Imported = append(result.Imported, path)
+		for _, attachment := range attachments {
+			err = attach(dir, path, attachment, dest, opts)
+			if err != nil {
+				fmt.Fprintf(out, "%s: %s: %s\n", progress, attachment, err)
+				result.Failed[attachment] = fmt.Errorf("attaching to %s: %w", path, err)
+				continue
+			}
+
+			fmt.Fprintf(out, "%s: attached %s\n", progress, attachment)
+			result.Attached = append(result.Attached, attachment)
+		}
+	}
+
+	return result, nil
+}
+
+// attach uploads the file at attachment in dir as an attachment of the
+// brainfile imported from notePath.
+func attach(dir, notePath, attachment string, dest Uploader, opts Options) error {
+	data, err := os.ReadFile(filepath.Join(dir, attachment))
+	if err != nil || opts.DryRun {
+		return err
+	}
+
+	return dest.Put(filepath.Join(opts.Prefix, brain.AssetsDir(notePath), filepath.Base(attachment)), data)
+}
@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jedrw/brain/internal/brain"
+)
+
+type fakeUploader map[string][]byte
+
+func (f fakeUploader) Put(path string, data []byte) error {
+	f[path] = data
+	return nil
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		body     string
+		path     string
+		expected string
+	}{
+		{"intro\n# Deploying #\n## Steps", "ops/deploy.md", "Deploying"},
+		{"```\n# not a heading\n```\ntext", "ops/run-book_v2.md", "run book v2"},
+		{"## Only subheadings", "notes.md", "notes"},
+	}
+
+	for _, test := range tests {
+		got := Title([]byte(test.body), test.path)
+		if got != test.expected {
+			t.Errorf("%q: expected %q got %q", test.body, test.expected, got)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"titled.md":           "---\ntitle: Titled\ntags: [a]\n---\nbody\n",
+		"nested/heading.md":   "# From Heading\nbody\n",
+		"empty-title.md":      "---\ntitle: \"\"\ntags: [b]\n---\nbody\n",
+		"invalid.md":          "---\ntitle: [not, a, string]\n---\n",
+		".obsidian/ignore.md": "# Ignored\n",
+		"image.png":           "",
+	}
+
+	for path, content := range files {
+		err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0770)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filepath.Join(dir, path), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := fakeUploader{}
+	result, err := Import(dir, dest, Options{Prefix: "imported"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Imported) != 3 || len(dest) != 3 {
+		t.Fatalf("expected 3 imported got %v", result.Imported)
+	}
+
+	if _, ok := result.Failed["invalid.md"]; !ok || len(result.Failed) != 1 {
+		t.Errorf("expected invalid.md to fail got %v", result.Failed)
+	}
+
+	expected := map[string]string{
+		"imported/titled.md":         "Titled",
+		"imported/nested/heading.md": "From Heading",
+		"imported/empty-title.md":    "empty title",
+	}
+
+	for path, title := range expected {
+		node, err := brain.NewNodeFromBytes(dest[path])
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+
+		if node.Title != title {
+			t.Errorf("%s: expected title %q got %q", path, title, node.Title)
+		}
+	}
+
+	if !strings.Contains(string(dest["imported/empty-title.md"]), "tags:\n- b\n") {
+		t.Errorf("expected existing frontmatter to be preserved got:\n%s", dest["imported/empty-title.md"])
+	}
+
+	dest = fakeUploader{}
+	_, err = Import(dir, dest, Options{DryRun: true}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dest) != 0 {
+		t.Errorf("expected dry run to upload nothing got %d", len(dest))
+	}
+}
+
+func TestImportObsidianAttachments(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"ops/Deploy.md":           "![[diagram.png]] ![[missing.png]]\n",
+		"attachments/diagram.png": "png",
+	} {
+		err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0770)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filepath.Join(dir, path), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := fakeUploader{}
+	result, err := Import(dir, dest, Options{Prefix: "vault", Obsidian: true}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Failed) != 0 || len(result.Attached) != 1 {
+		t.Fatalf("expected diagram.png to be attached got %v, failed %v", result.Attached, result.Failed)
+	}
+
+	if string(dest["vault/ops/Deploy.assets/diagram.png"]) != "png" {
+		t.Errorf("expected diagram.png to be uploaded as an attachment got %v", dest)
+	}
+
+	if !strings.Contains(string(dest["vault/ops/Deploy.md"]), "![diagram.png](Deploy.assets/diagram.png)") {
+		t.Errorf("expected the embed to link to the attachment got:\n%s", dest["vault/ops/Deploy.md"])
+	}
+}
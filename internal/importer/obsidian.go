@@ -0,0 +1,234 @@
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jedrw/brain/internal/brain"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	wikilinkRegexp  = regexp.MustCompile(`(!?)\[\[([^\]|#]*)(#[^\]|]*)?(?:\|([^\]]*))?\]\]`)
+	inlineTagRegexp = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+)
+
+// vault resolves Obsidian link targets to paths relative to the vault root.
+type vault struct {
+	paths map[string]string
+	names map[string]string
+}
+
+// newVault indexes notes by name and path without their extension, and
+// other files by name and path with it, as Obsidian links to them.
+func newVault(paths []string) *vault {
+	v := &vault{paths: map[string]string{}, names: map[string]string{}}
+	// Shorter paths win when names are ambiguous, as in Obsidian
+	slices.SortStableFunc(paths, func(a, b string) int {
+		return strings.Count(a, "/") - strings.Count(b, "/")
+	})
+
+	for _, path := range paths {
+		path = filepath.ToSlash(path)
+		key := path
+		if filepath.Ext(path) == ".md" {
+			key = strings.TrimSuffix(path, ".md")
+		}
+
+		v.paths[strings.ToLower(key)] = path
+		name := strings.ToLower(filepath.Base(key))
+		if _, ok := v.names[name]; !ok {
+			v.names[name] = path
+		}
+	}
+
+	return v
+}
+
+// resolve returns the vault path target refers to and whether it is in the
+// vault. Unresolved note links are kept as a path from the vault root so they
+// work if the note is created.
+func (v *vault) resolve(target string) (string, bool) {
+	key := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(target, "/"), ".md"))
+	if path, ok := v.paths[key]; ok {
+		return path, true
+	}
+
+	if path, ok := v.names[filepath.Base(key)]; ok {
+		return path, true
+	}
+
+	if filepath.Ext(target) == "" {
+		return target + ".md", false
+	}
+
+	return target, false
+}
+
+func linkDestination(path string) string {
+	if strings.ContainsAny(path, " ()<>") {
+		return "<" + path + ">"
+	}
+
+	return path
+}
+
+// convertLinks rewrites the wikilinks and embeds in a line of markdown from
+// the note at from into standard markdown links. Embedded files in the vault
+// are linked as attachments of the note and their vault paths added to
+// attachments.
+func (v *vault) convertLinks(from, line string, attachments *[]string) string {
+	return wikilinkRegexp.ReplaceAllStringFunc(line, func(match string) string {
+		groups := wikilinkRegexp.FindStringSubmatch(match)
+		embed, target, heading, alias := groups[1] == "!", strings.TrimSpace(groups[2]), groups[3], groups[4]
+		heading = strings.TrimPrefix(heading, "#")
+		embedFile := embed && filepath.Ext(target) != "" && filepath.Ext(target) != ".md"
+
+		destination := ""
+		if target != "" {
+			path, ok := v.resolve(target)
+			if embedFile && ok {
+				if !slices.Contains(*attachments, path) {
+					*attachments = append(*attachments, path)
+				}
+
+				destination = filepath.ToSlash(filepath.Join(filepath.Base(brain.AssetsDir(from)), filepath.Base(path)))
+			} else {
+				rel, err := filepath.Rel(filepath.Dir(from), path)
+				if err != nil {
+					return match
+				}
+
+				destination = filepath.ToSlash(rel)
+			}
+		}
+
+		// Block references have no markdown equivalent so link to the note
+		if heading != "" && !strings.HasPrefix(heading, "^") {
+			destination += "#" + brain.HeadingAnchor(heading)
+		}
+
+		text := alias
+		if text == "" {
+			var parts []string
+			if target != "" {
+				parts = append(parts, target)
+			}
+
+			if heading != "" && !strings.HasPrefix(heading, "^") {
+				parts = append(parts, heading)
+			}
+
+			text = strings.Join(parts, " > ")
+		}
+
+		if embedFile {
+			return fmt.Sprintf("![%s](%s)", text, linkDestination(destination))
+		}
+
+		return fmt.Sprintf("[%s](%s)", text, linkDestination(destination))
+	})
+}
+
+// convertBody converts wikilinks and collects inline tags and embedded files
+// outside of code.
+func (v *vault) convertBody(from string, body []byte) ([]byte, []string, []string) {
+	var tags, attachments []string
+	var sb strings.Builder
+	fence := ""
+	for line := range strings.Lines(string(body)) {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+
+			sb.WriteString(line)
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			sb.WriteString(line)
+			continue
+		}
+
+		// Odd segments between backticks are inline code
+		segments := strings.Split(line, "`")
+		for i := 0; i < len(segments); i += 2 {
+			for _, match := range inlineTagRegexp.FindAllStringSubmatch(segments[i], -1) {
+				tags = append(tags, match[2])
+			}
+
+			segments[i] = v.convertLinks(from, segments[i], &attachments)
+		}
+
+		sb.WriteString(strings.Join(segments, "`"))
+	}
+
+	return []byte(sb.String()), tags, attachments
+}
+
+// frontmatterTags returns the tags from Obsidian's tags or tag frontmatter
+// which may be lists or space or comma separated strings with or without #.
+func frontmatterTags(frontmatter yaml.MapSlice) []string {
+	var tags []string
+	for _, key := range []string{"tags", "tag"} {
+		value, _ := brain.GetFrontmatter(frontmatter, key)
+		switch value := value.(type) {
+		case string:
+			tags = append(tags, strings.Fields(strings.ReplaceAll(value, ",", " "))...)
+		case []any:
+			for _, tag := range value {
+				tags = append(tags, fmt.Sprint(tag))
+			}
+		}
+	}
+
+	for i, tag := range tags {
+		tags[i] = strings.TrimPrefix(tag, "#")
+	}
+
+	return tags
+}
+
+// convertObsidian converts an Obsidian note into a brainfile, titled with its
+// file name as Obsidian displays it, with its frontmatter and inline tags
+// merged and its wikilinks rewritten as markdown links. The vault paths of
+// the files it embeds, which are linked as its attachments, are returned.
+func (v *vault) convertObsidian(filePath string, data []byte) ([]byte, []string, error) {
+	frontmatter, body, err := brain.SplitFrontmatter(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, inlineTags, attachments := v.convertBody(filepath.ToSlash(filePath), body)
+	tags := []string{}
+	for _, tag := range append(frontmatterTags(frontmatter), inlineTags...) {
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	frontmatter = slices.DeleteFunc(frontmatter, func(item yaml.MapItem) bool {
+		return item.Key == "tag" || item.Key == "tags"
+	})
+
+	name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	title, ok := brain.GetFrontmatter(frontmatter, "title")
+	if !ok {
+		frontmatter = append(yaml.MapSlice{{Key: "title", Value: name}}, frontmatter...)
+	} else if title == nil || title == "" {
+		frontmatter = brain.SetFrontmatter(frontmatter, "title", name)
+	}
+
+	if len(tags) > 0 {
+		frontmatter = brain.SetFrontmatter(frontmatter, "tags", tags)
+	}
+
+	data, err = brain.JoinFrontmatter(frontmatter, body)
+	return data, attachments, err
+}
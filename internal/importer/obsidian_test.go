@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jedrw/brain/internal/brain"
+)
+
+func TestConvertObsidian(t *testing.T) {
+	v := newVault([]string{
+		"Home.md",
+		"projects/Deploy Plan.md",
+		"projects/archive/Home.md",
+		"attachments/diagram.png",
+	})
+
+	note := strings.Join([]string{
+		"---",
+		"tags: [ops, \"#infra\"]",
+		"aliases: [plan]",
+		"---",
+		"# Overview #ops",
+		"See [[Home]] and [[Deploy Plan|the plan]] or [[Deploy Plan#Rollback Steps]].",
+		"## Café: Re-run (again)",
+		"Retry with [[#Café: Re-run (again)]].",
+		"Back to [[#Overview]], see [[Home#^block1]] and [[Missing Note]] #todo/later",
+		"![[diagram.png]] ![[missing.png]]",
+		"`[[not a link]] #notatag` and #2024 is not a tag",
+		"```",
+		"[[Home]] #code",
+		"```",
+		"",
+	}, "\n")
+
+	out, attachments, err := v.convertObsidian("projects/Deploy Plan.md", []byte(note))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(attachments) != 1 || attachments[0] != "attachments/diagram.png" {
+		t.Errorf("expected diagram.png to be attached got %v", attachments)
+	}
+
+	node, err := brain.NewNodeFromBytes(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Heading links resolve to the IDs headings are rendered with
+	if !strings.Contains(string(node.Content), `id="caf-re-run-again"`) {
+		t.Errorf("expected the linked heading to be rendered with its anchor got:\n%s", node.Content)
+	}
+
+	if node.Title != "Deploy Plan" {
+		t.Errorf("expected title from file name got %q", node.Title)
+	}
+
+	expectedTags := []string{"ops", "infra", "todo/later"}
+	if strings.Join(node.Tags, ",") != strings.Join(expectedTags, ",") {
+		t.Errorf("expected tags %v got %v", expectedTags, node.Tags)
+	}
+
+	for _, expected := range []string{
+		"aliases:\n- plan\n",
+		"Retry with [Café: Re-run (again)](#caf-re-run-again).",
+		"See [Home](../Home.md) and [the plan](<Deploy Plan.md>) or [Deploy Plan > Rollback Steps](<Deploy Plan.md#rollback-steps>).",
+		"Back to [Overview](#overview), see [Home](../Home.md) and [Missing Note](<../Missing Note.md>)",
+		"![diagram.png](<Deploy Plan.assets/diagram.png>) ![missing.png](../missing.png)",
+		"`[[not a link]] #notatag` and #2024",
+		"```\n[[Home]] #code\n```",
+	} {
+		if !strings.Contains(string(out), expected) {
+			t.Errorf("expected output to contain %q got:\n%s", expected, out)
+		}
+	}
+}
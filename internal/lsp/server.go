@@ -0,0 +1,601 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/jedrw/brain/internal/brain"
+)
+
+const (
+	CommandSave    = "brain.save"
+	CommandRefresh = "brain.refresh"
+
+	source = "brain"
+)
+
+var ErrNotInBrain = errors.New("document is not in the brain directory")
+
+// Remote is the brain served to the editor.
+type Remote interface {
+	Manifest(root string) ([]brain.ManifestEntry, error)
+	Get(path string) ([]byte, error)
+	Put(path string, data []byte) error
+}
+
+// Server is a language server for brainfiles in a local directory, such as a
+// mirror kept by brain sync, whose paths match those in the remote brain.
+type Server struct {
+	remote    Remote
+	root      string
+	manifest  []brain.ManifestEntry
+	documents map[string]string
+	out       io.Writer
+}
+
+func NewServer(remote Remote, root string) *Server {
+	return &Server{
+		remote:    remote,
+		root:      root,
+		documents: map[string]string{},
+	}
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported document uri %q", uri)
+	}
+
+	return filepath.FromSlash(u.Path), nil
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// brainPath returns the path in the brain of the document at uri.
+func (s *Server) brainPath(uri string) (string, error) {
+	filePath, err := uriToPath(uri)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(s.root, filePath)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s", ErrNotInBrain, filePath)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+func (s *Server) refresh() error {
+	manifest, err := s.remote.Manifest("")
+	if err != nil {
+		return err
+	}
+
+	for i := range manifest {
+		manifest[i].Path = filepath.ToSlash(manifest[i].Path)
+	}
+
+	s.manifest = manifest
+	return nil
+}
+
+func (s *Server) notify(method string, params any) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// Serve handles messages from r until the client exits.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	reader := bufio.NewReader(r)
+	for {
+		req, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+
+		var parseErr *parseError
+		if errors.As(err, &parseErr) {
+			err = writeMessage(w, errorResponse{JSONRPC: "2.0", Error: responseError{Code: codeParseError, Message: parseErr.Error()}})
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		if req.Method == "" {
+			// Responses to requests are not expected
+			continue
+		}
+
+		result, err := s.handle(req)
+		var rpcErr *rpcError
+		if req.ID == nil {
+			if err != nil && !errors.As(err, &rpcErr) {
+				log.Warn("failed to handle notification", "method", req.Method, "err", err)
+			}
+
+			continue
+		}
+
+		var msg any = response{JSONRPC: "2.0", ID: req.ID, Result: result}
+		if errors.As(err, &rpcErr) {
+			msg = errorResponse{JSONRPC: "2.0", ID: req.ID, Error: responseError{Code: rpcErr.code, Message: rpcErr.Error()}}
+		} else if err != nil {
+			msg = errorResponse{JSONRPC: "2.0", ID: req.ID, Error: responseError{Code: codeInternalError, Message: err.Error()}}
+		}
+
+		err = writeMessage(w, msg)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e *rpcError) Error() string {
+	return e.msg
+}
+
+// decode unmarshals params, which may be omitted.
+func decode(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(params, v)
+}
+
+func (s *Server) handle(req request) (any, error) {
+	switch req.Method {
+	case "initialized":
+		return nil, nil
+	case "initialize":
+		var params initializeParams
+		err := decode(req.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.initialize(params)
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		err := decode(req.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		s.documents[params.TextDocument.URI] = params.TextDocument.Text
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params didChangeParams
+		err := decode(req.Params, &params)
+		if err != nil || len(params.ContentChanges) == 0 {
+			return nil, err
+		}
+
+		// Documents are synced in full so the last change is the whole text
+		s.documents[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didSave":
+		var params didSaveParams
+		err := decode(req.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		if params.Text != nil {
+			s.documents[params.TextDocument.URI] = *params.Text
+		}
+
+		return nil, s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params didCloseParams
+		err := decode(req.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(s.documents, params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/completion":
+		var params positionParams
+		err := decode(req.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.completion(params)
+	case "textDocument/definition":
+		var params positionParams
+		err := decode(req.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.definition(params)
+	case "workspace/executeCommand":
+		var params executeCommandParams
+		err := decode(req.Params, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, s.executeCommand(params)
+	default:
+		return nil, &rpcError{code: codeMethodNotFound, msg: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+// workspaceRoot returns the editor's workspace directory, or the working
+// directory if it has none.
+func workspaceRoot(params initializeParams) (string, error) {
+	switch {
+	case params.RootURI != "":
+		return uriToPath(params.RootURI)
+	case params.RootPath != "":
+		return params.RootPath, nil
+	default:
+		return os.Getwd()
+	}
+}
+
+// initialize uses the editor's workspace as the brain directory unless the
+// server was given one.
+func (s *Server) initialize(params initializeParams) (any, error) {
+	var err error
+	if s.root == "" {
+		s.root, err = workspaceRoot(params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.refresh()
+	if err != nil {
+		log.Warn("could not fetch brain manifest", "err", err)
+	}
+
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    syncFull,
+				"save":      map[string]any{"includeText": true},
+			},
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{"[", "(", " "},
+			},
+			"definitionProvider": true,
+			"executeCommandProvider": map[string]any{
+				"commands": []string{CommandSave, CommandRefresh},
+			},
+		},
+		"serverInfo": map[string]any{"name": source},
+	}, nil
+}
+
+// text returns the text of an open document, or reads it from disk.
+func (s *Server) text(uri string) (string, error) {
+	text, ok := s.documents[uri]
+	if ok {
+		return text, nil
+	}
+
+	filePath, err := uriToPath(uri)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filePath)
+	return string(data), err
+}
+
+// diagnostics validates text as a brainfile and checks its links resolve to
+// brainfiles in the remote brain.
+func (s *Server) diagnostics(docPath, text string) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	_, err := brain.NewNodeFromBytes([]byte(text))
+	if err != nil {
+		docLines := lines(text)
+		end := max(frontmatterEnd(docLines), 0)
+		diagnostics = append(diagnostics, Diagnostic{
+			Range: Range{
+				End: Position{Line: end, Character: character(docLines[end], len(docLines[end]))},
+			},
+			Severity: severityError,
+			Source:   source,
+			Message:  err.Error(),
+		})
+	}
+
+	if s.manifest == nil {
+		return diagnostics
+	}
+
+	docLines := lines(text)
+	for _, l := range links(text) {
+		target, _, ok := linkTarget(docPath, l.target)
+		if !ok || s.entry(target) != nil {
+			continue
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range: Range{
+				Start: Position{Line: l.line, Character: character(docLines[l.line], l.start)},
+				End:   Position{Line: l.line, Character: character(docLines[l.line], l.end)},
+			},
+			Severity: severityWarning,
+			Source:   source,
+			Message:  fmt.Sprintf("%s is not in the brain", target),
+		})
+	}
+
+	return diagnostics
+}
+
+func (s *Server) entry(path string) *brain.ManifestEntry {
+	i := slices.IndexFunc(s.manifest, func(entry brain.ManifestEntry) bool {
+		return entry.Path == path
+	})
+	if i == -1 {
+		return nil
+	}
+
+	return &s.manifest[i]
+}
+
+func (s *Server) publishDiagnostics(uri string) error {
+	docPath, err := s.brainPath(uri)
+	if err != nil {
+		// Only brainfiles are checked
+		return nil
+	}
+
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: s.diagnostics(docPath, s.documents[uri]),
+	})
+}
+
+func (s *Server) tags() []string {
+	var tags []string
+	for _, entry := range s.manifest {
+		for _, tag := range entry.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	slices.Sort(tags)
+	return tags
+}
+
+// completion completes tags in the frontmatter, links to brainfiles by title
+// after [[, and link targets by path.
+func (s *Server) completion(params positionParams) ([]CompletionItem, error) {
+	docPath, err := s.brainPath(params.TextDocument.URI)
+	if err != nil {
+		// Only brainfiles are completed and linked
+		return nil, nil
+	}
+
+	text, err := s.text(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	docLines := lines(text)
+	if params.Position.Line >= len(docLines) {
+		return nil, nil
+	}
+
+	line := docLines[params.Position.Line]
+	prefix := line[:byteOffset(line, params.Position.Character)]
+	items := []CompletionItem{}
+	if inTags(docLines, params.Position.Line) {
+		for _, tag := range s.tags() {
+			items = append(items, CompletionItem{Label: tag, Kind: completionKeyword})
+		}
+
+		return items, nil
+	}
+
+	replace := func(start int) Range {
+		return Range{
+			Start: Position{Line: params.Position.Line, Character: character(line, start)},
+			End:   params.Position,
+		}
+	}
+
+	if start := strings.LastIndex(prefix, "[["); start != -1 && !strings.Contains(prefix[start:], "]") {
+		for _, entry := range s.manifest {
+			if entry.Path == docPath {
+				continue
+			}
+
+			items = append(items, CompletionItem{
+				Label:      entry.Title,
+				Kind:       completionFile,
+				Detail:     entry.Path,
+				FilterText: "[[" + entry.Title,
+				TextEdit: &TextEdit{
+					Range:   replace(start),
+					NewText: fmt.Sprintf("[%s](%s)", entry.Title, relativeLink(docPath, entry.Path)),
+				},
+			})
+		}
+
+		return items, nil
+	}
+
+	if match := linkTargetRegexp.FindStringSubmatchIndex(prefix); match != nil {
+		for _, entry := range s.manifest {
+			if entry.Path == docPath {
+				continue
+			}
+
+			link := relativeLink(docPath, entry.Path)
+			items = append(items, CompletionItem{
+				Label:    link,
+				Kind:     completionFile,
+				Detail:   entry.Title,
+				TextEdit: &TextEdit{Range: replace(match[2]), NewText: link},
+			})
+		}
+	}
+
+	return items, nil
+}
+
+// definition returns the location of the brainfile linked to under the
+// cursor, fetching it from the remote brain if it is not in the directory.
+func (s *Server) definition(params positionParams) ([]Location, error) {
+	docPath, err := s.brainPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, nil
+	}
+
+	text, err := s.text(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	docLines := lines(text)
+	for _, l := range links(text) {
+		if l.line != params.Position.Line {
+			continue
+		}
+
+		offset := byteOffset(docLines[l.line], params.Position.Character)
+		if offset < l.start || offset >= l.end {
+			continue
+		}
+
+		target, _, ok := linkTarget(docPath, l.target)
+		if !ok {
+			return nil, nil
+		}
+
+		filePath := filepath.Join(s.root, filepath.FromSlash(target))
+		_, err := os.Stat(filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			_, err = s.fetch(target, filePath)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []Location{{URI: pathToURI(filePath)}}, nil
+	}
+
+	return nil, nil
+}
+
+// fetch writes the brainfile at target in the remote brain to filePath,
+// returning its content.
+func (s *Server) fetch(target, filePath string) ([]byte, error) {
+	data, err := s.remote.Get(target)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0770)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, os.WriteFile(filePath, data, 0644)
+}
+
+// executeCommand saves the document given as the first argument to the remote
+// brain, writing back the version it stored, or refreshes the brainfiles known
+// to the server.
+func (s *Server) executeCommand(params executeCommandParams) error {
+	switch params.Command {
+	case CommandSave:
+		if len(params.Arguments) == 0 {
+			return fmt.Errorf("%s requires a document uri", CommandSave)
+		}
+
+		uri, ok := params.Arguments[0].(string)
+		if !ok {
+			return fmt.Errorf("%s requires a document uri", CommandSave)
+		}
+
+		docPath, err := s.brainPath(uri)
+		if err != nil {
+			return err
+		}
+
+		text, err := s.text(uri)
+		if err != nil {
+			return err
+		}
+
+		err = s.remote.Put(docPath, []byte(text))
+		if err != nil {
+			return err
+		}
+
+		// The remote stamps brainfiles as they are saved, so the stored
+		// version replaces the local one
+		filePath, err := uriToPath(uri)
+		if err != nil {
+			return err
+		}
+
+		stored, err := s.fetch(docPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := s.documents[uri]; ok {
+			s.documents[uri] = string(stored)
+		}
+
+		err = s.refresh()
+		if err != nil {
+			return err
+		}
+
+		return s.notify("window/showMessage", showMessageParams{Type: messageInfo, Message: fmt.Sprintf("saved %s", docPath)})
+	case CommandRefresh:
+		return s.refresh()
+	default:
+		return &rpcError{code: codeMethodNotFound, msg: fmt.Sprintf("unknown command: %s", params.Command)}
+	}
+}
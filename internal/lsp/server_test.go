@@ -0,0 +1,262 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jedrw/brain/internal/brain"
+)
+
+type fakeRemote struct {
+	files map[string]string
+}
+
+func (r *fakeRemote) Manifest(string) ([]brain.ManifestEntry, error) {
+	var entries []brain.ManifestEntry
+	for path, data := range r.files {
+		node, _ := brain.NewNodeFromBytes([]byte(data))
+		entries = append(entries, brain.ManifestEntry{Path: path, Title: node.Title, Tags: node.Tags})
+	}
+
+	return entries, nil
+}
+
+func (r *fakeRemote) Get(path string) ([]byte, error) {
+	data, ok := r.files[path]
+	if !ok {
+		return nil, brain.ErrNotExist
+	}
+
+	return []byte(data), nil
+}
+
+// Put stamps data as it is saved, as the server does.
+func (r *fakeRemote) Put(path string, data []byte) error {
+	r.files[path] = string(data) + "stamped\n"
+	return nil
+}
+
+type testClient struct {
+	t      *testing.T
+	input  bytes.Buffer
+	nextID int
+}
+
+func (c *testClient) send(id int, method string, params any) {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+	if id != 0 {
+		msg["id"] = id
+	}
+
+	err := writeMessage(&c.input, msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) request(method string, params any) int {
+	c.nextID++
+	c.send(c.nextID, method, params)
+	return c.nextID
+}
+
+type received struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func readAll(t *testing.T, out *bytes.Buffer) []received {
+	var messages []received
+	r := bufio.NewReader(out)
+	for r.Buffered() > 0 || out.Len() > 0 {
+		var length int
+		_, err := fmt.Fscanf(r, "Content-Length: %d\r\n\r\n", &length)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := make([]byte, length)
+		_, err = io.ReadFull(r, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msg received
+		err = json.Unmarshal(body, &msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	remote := &fakeRemote{files: map[string]string{
+		"ops/runbook.md": "---\ntitle: Runbook\ntags: [ops]\n---\n",
+		"db.md":          "---\ntitle: Database\ntags: [ops, db]\n---\n",
+	}}
+
+	runbook := "---\ntitle: Runbook\ntags:\n- \n---\n\nSee [db](../db.md) and [gone](gone.md)\n\n[[Dat"
+	uri := pathToURI(filepath.Join(root, "ops", "runbook.md"))
+	client := &testClient{t: t}
+	client.request("initialize", map[string]any{"rootUri": pathToURI(root)})
+	client.send(0, "initialized", map[string]any{})
+	client.send(0, "textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "markdown", "version": 1, "text": runbook},
+	})
+	tagsID := client.request("textDocument/completion", map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": 3, "character": 2},
+	})
+	linkID := client.request("textDocument/completion", map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": 8, "character": 5},
+	})
+	definitionID := client.request("textDocument/definition", map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": 6, "character": 6},
+	})
+	client.send(0, "textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []map[string]any{{"text": "---\ntitle: Runbook\n---\nUpdated\n"}},
+	})
+	saveID := client.request("workspace/executeCommand", map[string]any{
+		"command":   CommandSave,
+		"arguments": []any{uri},
+	})
+	unknownID := client.request("textDocument/hover", map[string]any{})
+	client.request("shutdown", nil)
+	client.send(0, "exit", nil)
+
+	var out bytes.Buffer
+	server := NewServer(remote, "")
+	err := server.Serve(&client.input, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := map[int]received{}
+	var diagnostics [][]Diagnostic
+	for _, msg := range readAll(t, &out) {
+		if msg.Method == "textDocument/publishDiagnostics" {
+			var params publishDiagnosticsParams
+			err = json.Unmarshal(msg.Params, &params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diagnostics = append(diagnostics, params.Diagnostics)
+		} else if msg.ID != 0 {
+			results[msg.ID] = msg
+		}
+	}
+
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected diagnostics on open and change got %v", diagnostics)
+	}
+
+	if len(diagnostics[0]) != 1 || diagnostics[0][0].Severity != severityWarning || diagnostics[0][0].Range.Start != (Position{Line: 6, Character: 23}) {
+		t.Errorf("expected a warning for the missing link got %+v", diagnostics[0])
+	}
+
+	if len(diagnostics[1]) != 0 {
+		t.Errorf("expected no diagnostics after the change got %+v", diagnostics[1])
+	}
+
+	var items []CompletionItem
+	json.Unmarshal(results[tagsID].Result, &items)
+	if len(items) != 2 || items[0].Label != "db" || items[1].Label != "ops" {
+		t.Errorf("expected tag completions got %+v", items)
+	}
+
+	json.Unmarshal(results[linkID].Result, &items)
+	if len(items) != 1 || items[0].TextEdit == nil || items[0].TextEdit.NewText != "[Database](../db.md)" || items[0].TextEdit.Range.Start != (Position{Line: 8}) {
+		t.Errorf("expected link completion got %+v", items)
+	}
+
+	var locations []Location
+	json.Unmarshal(results[definitionID].Result, &locations)
+	dbPath := filepath.Join(root, "db.md")
+	if len(locations) != 1 || locations[0].URI != pathToURI(dbPath) {
+		t.Errorf("expected definition in db.md got %+v", locations)
+	}
+
+	data, err := os.ReadFile(dbPath)
+	if err != nil || string(data) != remote.files["db.md"] {
+		t.Errorf("expected db.md to be fetched got %q %v", data, err)
+	}
+
+	stored := "---\ntitle: Runbook\n---\nUpdated\nstamped\n"
+	if results[saveID].Error != nil || remote.files["ops/runbook.md"] != stored {
+		t.Errorf("expected runbook to be saved got %+v %q", results[saveID].Error, remote.files["ops/runbook.md"])
+	}
+
+	data, err = os.ReadFile(filepath.Join(root, "ops", "runbook.md"))
+	if err != nil || string(data) != stored || server.documents[uri] != stored {
+		t.Errorf("expected the stored runbook to be written back got %q %q %v", data, server.documents[uri], err)
+	}
+
+	if results[unknownID].Error == nil || results[unknownID].Error.Code != codeMethodNotFound {
+		t.Errorf("expected method not found got %+v", results[unknownID])
+	}
+}
+
+func TestServeInvalidMessages(t *testing.T) {
+	client := &testClient{t: t}
+	client.input.WriteString("Content-Length: 5\r\n\r\n{bad}")
+	shutdownID := client.request("shutdown", nil)
+	client.send(0, "exit", nil)
+
+	var out bytes.Buffer
+	err := NewServer(&fakeRemote{}, "").Serve(&client.input, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := readAll(t, &out)
+	if len(messages) != 2 || messages[0].Error == nil || messages[0].Error.Code != codeParseError || messages[1].ID != shutdownID {
+		t.Errorf("expected a parse error then the shutdown response got %+v", messages)
+	}
+
+	for _, length := range []string{"-1", "1099511627776"} {
+		input := bytes.NewBufferString("Content-Length: " + length + "\r\n\r\n{}")
+		err = NewServer(&fakeRemote{}, "").Serve(input, io.Discard)
+		if err == nil {
+			t.Errorf("expected Content-Length %s to be rejected", length)
+		}
+	}
+}
+
+func TestRelativeLink(t *testing.T) {
+	for _, test := range [][3]string{
+		{"ops/runbook.md", "db.md", "../db.md"},
+		{"ops/runbook.md", "ops/deploy.md", "deploy.md"},
+		{"index.md", "ops/deploy.md", "ops/deploy.md"},
+		{"ops/a/b.md", "ops/c/d.md", "../c/d.md"},
+	} {
+		link := relativeLink(test[0], test[1])
+		if link != test[2] {
+			t.Errorf("%s to %s: expected %s got %s", test[0], test[1], test[2], link)
+		}
+	}
+}
+
+func TestOffsets(t *testing.T) {
+	line := "a😀b"
+	if byteOffset(line, 3) != 5 || character(line, 5) != 3 {
+		t.Errorf("expected surrogate pairs to count as two characters")
+	}
+}
@@ -0,0 +1,157 @@
+package lsp
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRegexp       = regexp.MustCompile(`\[[^\]]*\]\(([^)\s]+)\)`)
+	linkTargetRegexp = regexp.MustCompile(`\]\(([^)\s]*)$`)
+)
+
+func lines(text string) []string {
+	return strings.Split(text, "\n")
+}
+
+// byteOffset converts a UTF-16 character offset into line to a byte offset.
+func byteOffset(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i
+		}
+
+		units++
+		if r >= 0x10000 {
+			units++
+		}
+	}
+
+	return len(line)
+}
+
+// character converts a byte offset into line to a UTF-16 character offset.
+func character(line string, offset int) int {
+	units := 0
+	for _, r := range line[:offset] {
+		units++
+		if r >= 0x10000 {
+			units++
+		}
+	}
+
+	return units
+}
+
+func fence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// frontmatterEnd returns the index of the line closing the frontmatter, or -1
+// if there is none.
+func frontmatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return -1
+	}
+
+	for i, line := range lines[1:] {
+		if strings.TrimSpace(line) == "---" {
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// inTags reports whether line n is the tags key of the frontmatter or one of
+// its list items.
+func inTags(lines []string, n int) bool {
+	end := frontmatterEnd(lines)
+	if n <= 0 || n >= end {
+		return false
+	}
+
+	for i := n; i > 0; i-- {
+		if strings.HasPrefix(lines[i], "tags:") {
+			return true
+		}
+
+		if !strings.HasPrefix(strings.TrimSpace(lines[i]), "-") {
+			return false
+		}
+	}
+
+	return false
+}
+
+// linkTarget returns the brain path a link target refers to from the
+// brainfile at docPath, and its heading anchor, or false if it is not a
+// relative link to a brainfile.
+func linkTarget(docPath, target string) (string, string, bool) {
+	if strings.Contains(target, ":") || strings.HasPrefix(target, "/") {
+		return "", "", false
+	}
+
+	target, anchor, _ := strings.Cut(target, "#")
+	if !strings.HasSuffix(target, ".md") {
+		return "", "", false
+	}
+
+	resolved := path.Join(path.Dir(docPath), target)
+	if strings.HasPrefix(resolved, "../") || resolved == ".." {
+		return "", "", false
+	}
+
+	return resolved, anchor, true
+}
+
+// relativeLink returns the link from the brainfile at docPath to target.
+func relativeLink(docPath, target string) string {
+	from := strings.Split(path.Dir(docPath), "/")
+	to := strings.Split(target, "/")
+	if from[0] == "." {
+		from = nil
+	}
+
+	i := 0
+	for i < len(from) && i < len(to)-1 && from[i] == to[i] {
+		i++
+	}
+
+	return strings.Repeat("../", len(from)-i) + strings.Join(to[i:], "/")
+}
+
+type link struct {
+	line, start, end int
+	target           string
+}
+
+// links returns the markdown links in text outside of code blocks.
+func links(text string) []link {
+	var found []link
+	inCode := false
+	for n, line := range lines(text) {
+		if fence(line) {
+			inCode = !inCode
+			continue
+		}
+
+		if inCode {
+			continue
+		}
+
+		for _, match := range linkRegexp.FindAllStringSubmatchIndex(line, -1) {
+			found = append(found, link{
+				line:   n,
+				start:  match[0],
+				end:    match[1],
+				target: line[match[2]:match[3]],
+			})
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,294 @@
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jedrw/brain/internal/brain"
+)
+
+const (
+	StateFile      = ".brain-sync.json"
+	ConflictSuffix = ".conflict"
+)
+
+// Remote is the brain being mirrored.
+type Remote interface {
+	Manifest(root string) ([]brain.ManifestEntry, error)
+	Get(path string) ([]byte, error)
+	Put(path string, data []byte) error
+	Delete(path string) error
+}
+
+// state records the hash of each file as of the last sync, the common base
+// used to tell which side changed.
+type state struct {
+	Files map[string]string `json:"files"`
+}
+
+type Result struct {
+	Pulled    []string
+	Pushed    []string
+	Deleted   []string
+	Conflicts []string
+	Errors    map[string]error
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func loadState(dir string) (state, error) {
+	s := state{Files: map[string]string{}}
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return s, err
+	}
+
+	err = json.Unmarshal(data, &s)
+	if s.Files == nil {
+		s.Files = map[string]string{}
+	}
+
+	return s, err
+}
+
+func (s state) save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, StateFile), data, 0644)
+}
+
+// localHashes hashes every brainfile and attachment in the mirror.
+func localHashes(dir string) (map[string]string, error) {
+	hashes := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if brain.InAssetsDir(rel) {
+			if strings.HasSuffix(rel, ConflictSuffix) {
+				return nil
+			}
+		} else if !strings.HasSuffix(rel, ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		hashes[rel] = hash(data)
+		return nil
+	})
+
+	return hashes, err
+}
+
+func writeFile(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0770)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func pull(dir, path string, remote Remote) error {
+	data, err := remote.Get(path)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(dir, path), data)
+}
+
+// push saves the local file at path to remote, then writes the version
+// stored by remote, which stamps brainfiles as they are saved, back to the
+// mirror and returns its hash.
+func push(dir, path string, remote Remote) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		return "", err
+	}
+
+	err = remote.Put(path, data)
+	if err != nil {
+		return "", err
+	}
+
+	stored, err := remote.Get(path)
+	if err != nil {
+		return "", err
+	}
+
+	return hash(stored), writeFile(filepath.Join(dir, path), stored)
+}
+
+// reconcile applies the change to path given its remote, local and base
+// hashes, returning the new base. Once applied that is the hash both sides
+// share, or the remote hash for conflicts so the local resolution is pushed
+// next time.
+func reconcile(dir, path string, remote Remote, r, l, b string, result *Result, out io.Writer) (string, error) {
+	newBase := r
+	var err error
+	switch {
+	case r == l:
+		// In sync
+	case l == b && r == "":
+		fmt.Fprintf(out, "delete local %s\n", path)
+		err = os.Remove(filepath.Join(dir, path))
+		if err == nil {
+			result.Deleted = append(result.Deleted, path)
+		}
+	case l == b, l == "" && r != b:
+		// Changed remotely, or deleted locally but changed remotely so restore it
+		fmt.Fprintf(out, "pull %s\n", path)
+		err = pull(dir, path, remote)
+		if err == nil {
+			result.Pulled = append(result.Pulled, path)
+		}
+	case r == b && l == "":
+		fmt.Fprintf(out, "delete remote %s\n", path)
+		err = remote.Delete(path)
+		if err == nil {
+			newBase = ""
+			result.Deleted = append(result.Deleted, path)
+		}
+	case r == b:
+		fmt.Fprintf(out, "push %s\n", path)
+		newBase, err = push(dir, path, remote)
+		if err == nil {
+			result.Pushed = append(result.Pushed, path)
+		}
+	default:
+		fmt.Fprintf(out, "conflict %s\n", path)
+		result.Conflicts = append(result.Conflicts, path)
+		if r == "" {
+			return newBase, nil
+		}
+
+		var data []byte
+		data, err = remote.Get(path)
+		if err == nil {
+			err = writeFile(filepath.Join(dir, path+ConflictSuffix), data)
+		}
+	}
+
+	return newBase, err
+}
+
+// unresolved reports whether the .conflict copy of path is still present.
+func unresolved(dir, path string) bool {
+	_, err := os.Stat(filepath.Join(dir, path+ConflictSuffix))
+	return err == nil
+}
+
+// Sync reconciles the mirror in dir with remote. Changes on one side since
+// the last sync are applied to the other, and files changed on both sides are
+// left as the local version alongside a .conflict copy of the remote one.
+// Paths are skipped until their .conflict copy is removed.
+func Sync(dir string, remote Remote, out io.Writer) (Result, error) {
+	result := Result{Errors: map[string]error{}}
+	err := os.MkdirAll(dir, 0770)
+	if err != nil {
+		return result, err
+	}
+
+	base, err := loadState(dir)
+	if err != nil {
+		return result, err
+	}
+
+	manifest, err := remote.Manifest("")
+	if err != nil {
+		return result, err
+	}
+
+	remoteHashes := map[string]string{}
+	for _, entry := range manifest {
+		remoteHashes[entry.Path] = entry.Hash
+		maps.Copy(remoteHashes, entry.AttachmentHashes)
+	}
+
+	local, err := localHashes(dir)
+	if err != nil {
+		return result, err
+	}
+
+	paths := map[string]bool{}
+	for _, hashes := range []map[string]string{remoteHashes, local, base.Files} {
+		for path := range hashes {
+			paths[path] = true
+		}
+	}
+
+	// Brainfiles are reconciled before attachments, which can only be pushed
+	// once their brainfile exists
+	sorted := slices.SortedFunc(maps.Keys(paths), func(a, b string) int {
+		if brain.InAssetsDir(a) != brain.InAssetsDir(b) {
+			if brain.InAssetsDir(a) {
+				return 1
+			}
+
+			return -1
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	for _, path := range sorted {
+		r, l, b := remoteHashes[path], local[path], base.Files[path]
+		if unresolved(dir, path) {
+			// The base is the remote version the conflict was found with, so
+			// leave both sides alone until the .conflict file is removed
+			fmt.Fprintf(out, "conflict %s\n", path)
+			result.Conflicts = append(result.Conflicts, path)
+			continue
+		}
+
+		newBase, err := reconcile(dir, path, remote, r, l, b, &result, out)
+		if err != nil {
+			fmt.Fprintf(out, "error %s: %s\n", path, err)
+			result.Errors[path] = err
+			continue
+		}
+
+		if newBase == "" {
+			delete(base.Files, path)
+		} else {
+			base.Files[path] = newBase
+		}
+	}
+
+	return result, base.save(dir)
+}
@@ -0,0 +1,235 @@
+package mirror
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jedrw/brain/internal/brain"
+)
+
+type fakeRemote map[string][]byte
+
+func (f fakeRemote) Manifest(string) ([]brain.ManifestEntry, error) {
+	entries := map[string]*brain.ManifestEntry{}
+	for path, data := range f {
+		if _, _, ok := brain.AttachmentOf(path); !ok {
+			entries[path] = &brain.ManifestEntry{Path: path, Hash: hash(data)}
+		}
+	}
+
+	for path, data := range f {
+		if note, _, ok := brain.AttachmentOf(path); ok {
+			if entries[note] == nil {
+				continue
+			}
+
+			if entries[note].AttachmentHashes == nil {
+				entries[note].AttachmentHashes = map[string]string{}
+			}
+
+			entries[note].AttachmentHashes[path] = hash(data)
+		}
+	}
+
+	var manifest []brain.ManifestEntry
+	for _, entry := range entries {
+		manifest = append(manifest, *entry)
+	}
+
+	return manifest, nil
+}
+
+func (f fakeRemote) Get(path string) ([]byte, error) {
+	return f[path], nil
+}
+
+func (f fakeRemote) Put(path string, data []byte) error {
+	if note, _, ok := brain.AttachmentOf(path); ok && f[note] == nil {
+		return fmt.Errorf("%s does not exist", note)
+	}
+
+	f[path] = data
+	return nil
+}
+
+func (f fakeRemote) Delete(path string) error {
+	delete(f, path)
+	return nil
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	remote := fakeRemote{
+		"a.md":        []byte("a"),
+		"nested/b.md": []byte("b"),
+		"c.md":        []byte("c"),
+	}
+
+	_, err := Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if readFile(t, filepath.Join(dir, "nested", "b.md")) != "b" {
+		t.Error("expected initial sync to pull nested/b.md")
+	}
+
+	// Local edit, remote edit, conflicting edit, local delete, remote add
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("a local"), 0644)
+	remote["nested/b.md"] = []byte("b remote")
+	os.WriteFile(filepath.Join(dir, "c.md"), []byte("c local"), 0644)
+	remote["c.md"] = []byte("c remote")
+	remote["d.md"] = []byte("d")
+
+	result, err := Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(remote["a.md"]) != "a local" {
+		t.Errorf("expected a.md to be pushed, got %q", remote["a.md"])
+	}
+
+	if readFile(t, filepath.Join(dir, "nested", "b.md")) != "b remote" {
+		t.Error("expected nested/b.md to be pulled")
+	}
+
+	if readFile(t, filepath.Join(dir, "c.md")) != "c local" || readFile(t, filepath.Join(dir, "c.md"+ConflictSuffix)) != "c remote" {
+		t.Error("expected c.md to keep local version with remote conflict copy")
+	}
+
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != "c.md" {
+		t.Errorf("expected c.md conflict, got %v", result.Conflicts)
+	}
+
+	// Syncing again before the conflict is resolved keeps the remote edit
+	os.WriteFile(filepath.Join(dir, "c.md"), []byte("c merging"), 0644)
+	result, err = Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(remote["c.md"]) != "c remote" || len(result.Conflicts) != 1 || len(result.Pushed) != 0 {
+		t.Errorf("expected unresolved c.md to be skipped, got %q %+v", remote["c.md"], result)
+	}
+
+	// Resolving the conflict locally pushes it, deletes propagate
+	os.WriteFile(filepath.Join(dir, "c.md"), []byte("c merged"), 0644)
+	os.Remove(filepath.Join(dir, "c.md"+ConflictSuffix))
+	os.Remove(filepath.Join(dir, "d.md"))
+	delete(remote, "a.md")
+
+	_, err = Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(remote["c.md"]) != "c merged" {
+		t.Errorf("expected resolved c.md to be pushed, got %q", remote["c.md"])
+	}
+
+	if _, ok := remote["d.md"]; ok {
+		t.Error("expected d.md to be deleted remotely")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a.md")); !os.IsNotExist(err) {
+		t.Error("expected a.md to be deleted locally")
+	}
+}
+
+func TestSyncAttachments(t *testing.T) {
+	dir := t.TempDir()
+	remote := fakeRemote{
+		"ops/deploy.md":                 []byte("![](deploy.assets/diagram.png)"),
+		"ops/deploy.assets/diagram.png": []byte("png"),
+	}
+
+	_, err := Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if readFile(t, filepath.Join(dir, "ops", "deploy.assets", "diagram.png")) != "png" {
+		t.Error("expected attachment to be pulled")
+	}
+
+	// A new brainfile with an attachment, which sorts before its brainfile,
+	// and a local attachment delete
+	err = os.MkdirAll(filepath.Join(dir, "a.assets"), 0770)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.assets", "photo.jpg"), []byte("jpg"), 0644)
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("![](a.assets/photo.jpg)"), 0644)
+	os.Remove(filepath.Join(dir, "ops", "deploy.assets", "diagram.png"))
+
+	result, err := Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("expected no errors got %v", result.Errors)
+	}
+
+	if string(remote["a.assets/photo.jpg"]) != "jpg" {
+		t.Errorf("expected attachment to be pushed after its brainfile, got %q", remote["a.assets/photo.jpg"])
+	}
+
+	if _, ok := remote["ops/deploy.assets/diagram.png"]; ok {
+		t.Error("expected attachment to be deleted remotely")
+	}
+}
+
+// stampingRemote stamps files as they are saved, as the server does.
+type stampingRemote struct {
+	fakeRemote
+}
+
+func (s stampingRemote) Put(path string, data []byte) error {
+	return s.fakeRemote.Put(path, append(data, "updated: now\n"...))
+}
+
+func TestSyncStampedPush(t *testing.T) {
+	dir := t.TempDir()
+	remote := stampingRemote{fakeRemote{}}
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("a\n"), 0644)
+
+	result, err := Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Pushed) != 1 || readFile(t, filepath.Join(dir, "a.md")) != "a\nupdated: now\n" {
+		t.Fatalf("expected the stamped version to be written back after pushing got %q %+v", readFile(t, filepath.Join(dir, "a.md")), result)
+	}
+
+	// Editing the stamped version locally is a clean push, not a conflict
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("a edited\nupdated: now\n"), 0644)
+	result, err = Sync(dir, remote, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Conflicts) != 0 || len(result.Pushed) != 1 {
+		t.Errorf("expected the edit to be pushed without a conflict got %+v", result)
+	}
+
+	if string(remote.fakeRemote["a.md"]) != "a edited\nupdated: now\nupdated: now\n" {
+		t.Errorf("expected the edit to be pushed got %q", remote.fakeRemote["a.md"])
+	}
+}
@@ -0,0 +1,198 @@
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrConflict     = errors.New("patch does not apply")
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	hunkRegexp = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+)
+
+// hunk is a change to a run of lines. Lines keep their line endings.
+type hunk struct {
+	oldStart int
+	old      []string
+	new      []string
+}
+
+func lines(data []byte) []string {
+	return strings.SplitAfter(string(data), "\n")
+}
+
+func count(match string) (int, error) {
+	if match == "" {
+		return 1, nil
+	}
+
+	return strconv.Atoi(match)
+}
+
+// parse reads the hunks of a unified diff of a single file.
+func parse(diff []byte) ([]hunk, error) {
+	var hunks []hunk
+	var current *hunk
+	oldLeft, newLeft := 0, 0
+	// The last old and new lines read, trimmed by "\ No newline at end of file"
+	var lastOld, lastNew *string
+	for i, line := range lines(diff) {
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, `\`):
+			if lastOld == nil && lastNew == nil {
+				return nil, fmt.Errorf("%w: line %d: unexpected %q", ErrInvalidPatch, i+1, strings.TrimSpace(line))
+			}
+
+			for _, last := range []*string{lastOld, lastNew} {
+				if last != nil {
+					*last = strings.TrimSuffix(*last, "\n")
+				}
+			}
+		case oldLeft > 0 || newLeft > 0:
+			text := line[1:]
+			if line == "\n" {
+				// Some editors strip the space from empty context lines
+				text = "\n"
+			}
+
+			lastOld, lastNew = nil, nil
+			switch line[0] {
+			case ' ', '\n':
+				current.old = append(current.old, text)
+				current.new = append(current.new, text)
+				lastOld, lastNew = &current.old[len(current.old)-1], &current.new[len(current.new)-1]
+				oldLeft--
+				newLeft--
+			case '-':
+				current.old = append(current.old, text)
+				lastOld = &current.old[len(current.old)-1]
+				oldLeft--
+			case '+':
+				current.new = append(current.new, text)
+				lastNew = &current.new[len(current.new)-1]
+				newLeft--
+			default:
+				return nil, fmt.Errorf("%w: line %d: unexpected %q", ErrInvalidPatch, i+1, strings.TrimSpace(line))
+			}
+
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("%w: line %d: hunk longer than its header", ErrInvalidPatch, i+1)
+			}
+		case strings.HasPrefix(line, "@@"):
+			match := hunkRegexp.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("%w: line %d: malformed hunk header", ErrInvalidPatch, i+1)
+			}
+
+			oldStart, _ := strconv.Atoi(match[1])
+			var err error
+			oldLeft, err = count(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: malformed hunk header", ErrInvalidPatch, i+1)
+			}
+
+			newLeft, err = count(match[4])
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: malformed hunk header", ErrInvalidPatch, i+1)
+			}
+
+			hunks = append(hunks, hunk{oldStart: oldStart})
+			current = &hunks[len(hunks)-1]
+			lastOld, lastNew = nil, nil
+		case strings.HasPrefix(line, "--- ") && len(hunks) > 0:
+			return nil, fmt.Errorf("%w: patch must only change one file", ErrInvalidPatch)
+		default:
+			// Headers and anything else between hunks
+			lastOld, lastNew = nil, nil
+		}
+	}
+
+	if oldLeft > 0 || newLeft > 0 {
+		return nil, fmt.Errorf("%w: hunk %d is shorter than its header", ErrInvalidPatch, len(hunks))
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("%w: no hunks found", ErrInvalidPatch)
+	}
+
+	return hunks, nil
+}
+
+func matches(data []string, at int, old []string) bool {
+	if at < 0 || at+len(old) > len(data) {
+		return false
+	}
+
+	for i, line := range old {
+		if data[at+i] != line {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply applies a unified diff to data. Hunks may have moved from the line
+// numbers in their headers but their context must match exactly, otherwise
+// ErrConflict is returned.
+func Apply(data, diff []byte) ([]byte, error) {
+	hunks, err := parse(diff)
+	if err != nil {
+		return nil, err
+	}
+
+	original := lines(data)
+	if original[len(original)-1] == "" {
+		original = original[:len(original)-1]
+	}
+
+	var patched []string
+	next, offset := 0, 0
+	for i, h := range hunks {
+		// Hunks that only add lines give the line they follow
+		want := h.oldStart - 1 + offset
+		if len(h.old) == 0 {
+			want++
+		}
+
+		at := -1
+		for delta := 0; want-delta >= next || want+delta <= len(original); delta++ {
+			if want-delta >= next && matches(original, want-delta, h.old) {
+				at = want - delta
+				break
+			}
+
+			if matches(original, want+delta, h.old) {
+				at = want + delta
+				break
+			}
+		}
+
+		if at == -1 {
+			return nil, fmt.Errorf("%w: hunk %d at line %d", ErrConflict, i+1, h.oldStart)
+		}
+
+		offset = at - (want - offset)
+		patched = append(patched, original[next:at]...)
+		patched = append(patched, h.new...)
+		next = at + len(h.old)
+	}
+
+	patched = append(patched, original[next:]...)
+	var buf bytes.Buffer
+	for _, line := range patched {
+		buf.WriteString(line)
+	}
+
+	return buf.Bytes(), nil
+}
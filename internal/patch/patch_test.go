@@ -0,0 +1,97 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+)
+
+const original = `---
+title: Deploys
+---
+
+# Deploys
+
+- v1.0
+- v1.1
+
+## Notes
+
+Nothing yet.
+`
+
+func TestApply(t *testing.T) {
+	for name, test := range map[string]struct {
+		diff     string
+		expected string
+	}{
+		"change": {
+			diff: `--- a/deploys.md
++++ b/deploys.md
+@@ -6,4 +6,5 @@
+ 
+ - v1.0
+ - v1.1
++- v1.2
+ 
+@@ -11,2 +12,2 @@
+ 
+-Nothing yet.
++Rolled back v1.1 once.
+`,
+			expected: "---\ntitle: Deploys\n---\n\n# Deploys\n\n- v1.0\n- v1.1\n- v1.2\n\n## Notes\n\nRolled back v1.1 once.\n",
+		},
+		"moved": {
+			diff: `@@ -2,2 +2,2 @@
+ - v1.0
+-- v1.1
++- v1.1 (hotfix)
+`,
+			expected: "---\ntitle: Deploys\n---\n\n# Deploys\n\n- v1.0\n- v1.1 (hotfix)\n\n## Notes\n\nNothing yet.\n",
+		},
+		"insert at start": {
+			diff: `@@ -0,0 +1 @@
++<!-- generated -->
+`,
+			expected: "<!-- generated -->\n" + original,
+		},
+		"no newline at end of file": {
+			diff: `@@ -12 +12 @@
+-Nothing yet.
++Nothing yet.
+\ No newline at end of file
+`,
+			expected: original[:len(original)-1],
+		},
+	} {
+		patched, err := Apply([]byte(original), []byte(test.diff))
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+
+		if string(patched) != test.expected {
+			t.Errorf("%s: expected:\n%q\ngot:\n%q", name, test.expected, patched)
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	for name, test := range map[string]struct {
+		diff     string
+		expected error
+	}{
+		"conflict": {
+			diff:     "@@ -7,2 +7,2 @@\n - v1.0\n-- v1.2\n+- v1.3\n",
+			expected: ErrConflict,
+		},
+		"no hunks":      {diff: "not a patch\n", expected: ErrInvalidPatch},
+		"short hunk":    {diff: "@@ -7,3 +7,3 @@\n- v1.0\n", expected: ErrInvalidPatch},
+		"invalid line":  {diff: "@@ -7,2 +7,2 @@\n- v1.0\n*- v1.1\n", expected: ErrInvalidPatch},
+		"several files": {diff: "--- a/a.md\n+++ b/a.md\n@@ -7 +7 @@\n-- v1.0\n+- v0.9\n--- a/b.md\n+++ b/b.md\n", expected: ErrInvalidPatch},
+	} {
+		_, err := Apply([]byte(original), []byte(test.diff))
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v got %v", name, test.expected, err)
+		}
+	}
+}
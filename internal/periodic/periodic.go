@@ -0,0 +1,145 @@
+package periodic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Period string
+
+const (
+	Daily  Period = "daily"
+	Weekly Period = "weekly"
+)
+
+var tokenRegexp = regexp.MustCompile(`\{\{(year|month|day|date|week)\}\}`)
+
+// Start returns the date starting the period containing t, Monday for weeks
+// as in ISO 8601.
+func Start(period Period, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if period == Weekly {
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+
+	return day
+}
+
+// Path returns the brainfile path of the period containing t given a pattern
+// of {{year}}, {{month}}, {{day}}, {{date}} and {{week}}. Weekly paths use
+// the ISO year and week, and the date of the Monday starting them.
+func Path(pattern string, period Period, t time.Time) string {
+	start := Start(period, t)
+	year, week := start.ISOWeek()
+	if period == Daily {
+		year = start.Year()
+	}
+
+	return tokenRegexp.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch strings.Trim(token, "{}") {
+		case "year":
+			return strconv.Itoa(year)
+		case "month":
+			return fmt.Sprintf("%02d", start.Month())
+		case "day":
+			return fmt.Sprintf("%02d", start.Day())
+		case "date":
+			return start.Format(time.DateOnly)
+		case "week":
+			return fmt.Sprintf("%02d", week)
+		}
+
+		return token
+	})
+}
+
+// Title returns the title of the period containing t.
+func Title(period Period, t time.Time) string {
+	start := Start(period, t)
+	if period == Weekly {
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+
+	return start.Format(time.DateOnly)
+}
+
+// Parse returns the start of the period a path matching pattern is for.
+func Parse(pattern string, period Period, path string) (time.Time, bool) {
+	// Build an expression from the literal parts of the pattern with a group
+	// for each token
+	var tokens []string
+	var sb strings.Builder
+	last := 0
+	for _, loc := range tokenRegexp.FindAllStringSubmatchIndex(pattern, -1) {
+		sb.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		token := pattern[loc[2]:loc[3]]
+		tokens = append(tokens, token)
+		switch token {
+		case "year":
+			sb.WriteString(`(\d{4})`)
+		case "date":
+			sb.WriteString(`(\d{4}-\d{2}-\d{2})`)
+		default:
+			sb.WriteString(`(\d{2})`)
+		}
+
+		last = loc[1]
+	}
+	sb.WriteString(regexp.QuoteMeta(pattern[last:]))
+
+	match := regexp.MustCompile("^" + sb.String() + "$").FindStringSubmatch(path)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	values := map[string]string{}
+	for i, token := range tokens {
+		if previous, ok := values[token]; ok && previous != match[i+1] {
+			return time.Time{}, false
+		}
+
+		values[token] = match[i+1]
+	}
+
+	if date, ok := values["date"]; ok {
+		t, err := time.Parse(time.DateOnly, date)
+		return Start(period, t), err == nil
+	}
+
+	year, _ := strconv.Atoi(values["year"])
+	if week, ok := values["week"]; ok {
+		w, _ := strconv.Atoi(week)
+		// January 4th is always in the first ISO week
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+		return Start(Weekly, jan4).AddDate(0, 0, (w-1)*7), true
+	}
+
+	month, monthOk := values["month"]
+	day, dayOk := values["day"]
+	if !monthOk || !dayOk {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.DateOnly, fmt.Sprintf("%04d-%s-%s", year, month, day))
+	return Start(period, t), err == nil
+}
+
+// Previous returns the most recent path matching pattern for a period
+// before the one starting at start, or an empty string if there isn't one.
+func Previous(pattern string, period Period, start time.Time, paths []string) string {
+	var previous string
+	var previousStart time.Time
+	for _, path := range paths {
+		t, ok := Parse(pattern, period, path)
+		if ok && t.Before(start) && t.After(previousStart) {
+			previous = path
+			previousStart = t
+		}
+	}
+
+	return previous
+}
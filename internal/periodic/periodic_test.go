@@ -0,0 +1,71 @@
+package periodic
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	dailyPattern  = "journal/{{year}}/{{month}}/{{date}}.md"
+	weeklyPattern = "journal/{{year}}/{{year}}-W{{week}}.md"
+)
+
+func TestPath(t *testing.T) {
+	sunday := time.Date(2026, 10, 18, 22, 30, 0, 0, time.UTC)
+	newYear := time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		pattern  string
+		period   Period
+		t        time.Time
+		expected string
+		title    string
+	}{
+		{dailyPattern, Daily, sunday, "journal/2026/10/2026-10-18.md", "2026-10-18"},
+		{weeklyPattern, Weekly, sunday, "journal/2026/2026-W42.md", "2026-W42"},
+		{"log/{{date}}.md", Weekly, sunday, "log/2026-10-12.md", "2026-W42"},
+		// 1st January 2027 is in the last ISO week of 2026
+		{weeklyPattern, Weekly, newYear, "journal/2026/2026-W53.md", "2026-W53"},
+		{"{{year}}/{{month}}/{{day}}.md", Daily, newYear, "2027/01/01.md", "2027-01-01"},
+	}
+
+	for _, test := range tests {
+		got := Path(test.pattern, test.period, test.t)
+		if got != test.expected {
+			t.Errorf("%s %s: expected %s got %s", test.pattern, test.t, test.expected, got)
+		}
+
+		if title := Title(test.period, test.t); title != test.title {
+			t.Errorf("%s %s: expected title %s got %s", test.period, test.t, test.title, title)
+		}
+
+		start, ok := Parse(test.pattern, test.period, got)
+		if !ok || !start.Equal(Start(test.period, test.t)) {
+			t.Errorf("%s: expected to parse %s got %s", got, Start(test.period, test.t), start)
+		}
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	paths := []string{
+		"journal/2026/10/2026-10-14.md",
+		"journal/2026/09/2026-09-30.md",
+		"journal/2026/10/2026-10-18.md",
+		"journal/2026/10/notes.md",
+		"journal/2026/2026-W40.md",
+		"journal/2026/2026-W41.md",
+	}
+
+	start := Start(Daily, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))
+	if got := Previous(dailyPattern, Daily, start, paths); got != "journal/2026/10/2026-10-14.md" {
+		t.Errorf("expected previous daily 2026-10-14 got %q", got)
+	}
+
+	start = Start(Weekly, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))
+	if got := Previous(weeklyPattern, Weekly, start, paths); got != "journal/2026/2026-W41.md" {
+		t.Errorf("expected previous weekly W41 got %q", got)
+	}
+
+	if got := Previous(dailyPattern, Daily, start, paths[:0]); got != "" {
+		t.Errorf("expected no previous got %q", got)
+	}
+}
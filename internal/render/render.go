@@ -0,0 +1,32 @@
+package render
+
+import (
+	"github.com/charmbracelet/glamour"
+)
+
+const (
+	StyleAuto  = "auto"
+	StyleDark  = "dark"
+	StyleLight = "light"
+)
+
+// Markdown renders the body of a brainfile to ANSI styled text wrapped at
+// width.
+func Markdown(body []byte, width int, style string, options ...glamour.TermRendererOption) (string, error) {
+	styleOption := glamour.WithStandardStyle(style)
+	if style == StyleAuto {
+		styleOption = glamour.WithAutoStyle()
+	}
+
+	renderer, err := glamour.NewTermRenderer(
+		append([]glamour.TermRendererOption{
+			styleOption,
+			glamour.WithWordWrap(width),
+		}, options...)...,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return renderer.Render(string(body))
+}
@@ -0,0 +1,499 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jedrw/brain/internal/render"
+	"github.com/sahilm/fuzzy"
+)
+
+// Store is the brain the TUI browses and acts on.
+type Store interface {
+	Paths() []string
+	// Body returns the brainfile at path without its frontmatter.
+	Body(path string) ([]byte, error)
+	Move(from, to string) error
+	Delete(path string) error
+}
+
+type mode int
+
+const (
+	browsing mode = iota
+	finding
+	previewing
+	moving
+	deleting
+)
+
+type item struct {
+	name     string
+	path     string
+	isDir    bool
+	depth    int
+	children []*item
+}
+
+type styles struct {
+	title    lipgloss.Style
+	selected lipgloss.Style
+	dir      lipgloss.Style
+	help     lipgloss.Style
+	message  lipgloss.Style
+}
+
+type Model struct {
+	store    Store
+	renderer *lipgloss.Renderer
+	styles   styles
+	width    int
+	height   int
+
+	paths    []string
+	root     []*item
+	expanded map[string]bool
+	visible  []*item
+	cursor   int
+	offset   int
+
+	mode     mode
+	prev     mode
+	input    textinput.Model
+	matches  []string
+	viewport viewport.Model
+	current  string
+	message  string
+}
+
+func New(store Store, renderer *lipgloss.Renderer, width, height int) Model {
+	input := textinput.New()
+	input.Prompt = "> "
+
+	m := Model{
+		store:    store,
+		renderer: renderer,
+		styles: styles{
+			title:    renderer.NewStyle().Bold(true),
+			selected: renderer.NewStyle().Reverse(true),
+			dir:      renderer.NewStyle().Foreground(lipgloss.Color("12")),
+			help:     renderer.NewStyle().Faint(true),
+			message:  renderer.NewStyle().Foreground(lipgloss.Color("11")),
+		},
+		width:    width,
+		height:   height,
+		expanded: map[string]bool{},
+		input:    input,
+		viewport: viewport.New(width, max(height-2, 1)),
+	}
+	m.refresh()
+
+	return m
+}
+
+func (m *Model) refresh() {
+	m.paths = m.store.Paths()
+	slices.Sort(m.paths)
+	m.buildTree()
+}
+
+func (m *Model) buildTree() {
+	m.root = nil
+	dirs := map[string]*item{}
+	for _, path := range m.paths {
+		parts := strings.Split(path, string(filepath.Separator))
+		level := &m.root
+		for i, part := range parts {
+			relPath := filepath.Join(parts[:i+1]...)
+			if i == len(parts)-1 {
+				*level = append(*level, &item{name: part, path: relPath, depth: i})
+				break
+			}
+
+			dir, ok := dirs[relPath]
+			if !ok {
+				dir = &item{name: part, path: relPath, isDir: true, depth: i}
+				dirs[relPath] = dir
+				*level = append(*level, dir)
+			}
+
+			level = &dir.children
+		}
+	}
+
+	m.flatten()
+}
+
+func (m *Model) flatten() {
+	m.visible = nil
+	var walk func(items []*item)
+	walk = func(items []*item) {
+		for _, it := range items {
+			m.visible = append(m.visible, it)
+			if it.isDir && m.expanded[it.path] {
+				walk(it.children)
+			}
+		}
+	}
+	walk(m.root)
+	m.cursor = min(m.cursor, max(len(m.visible)-1, 0))
+}
+
+func (m *Model) listHeight() int {
+	return max(m.height-3, 1)
+}
+
+func (m *Model) listLength() int {
+	if m.mode == finding {
+		return len(m.matches)
+	}
+
+	return len(m.visible)
+}
+
+func (m *Model) moveCursor(delta int) {
+	m.cursor = max(min(m.cursor+delta, m.listLength()-1), 0)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+
+	if m.cursor >= m.offset+m.listHeight() {
+		m.offset = m.cursor - m.listHeight() + 1
+	}
+}
+
+// selected returns the path under the cursor and whether it is a directory.
+func (m *Model) selected() (string, bool) {
+	if m.mode == finding {
+		if m.cursor < len(m.matches) {
+			return m.matches[m.cursor], false
+		}
+
+		return "", false
+	}
+
+	if m.cursor < len(m.visible) {
+		return m.visible[m.cursor].path, m.visible[m.cursor].isDir
+	}
+
+	return "", false
+}
+
+func (m *Model) find() {
+	m.matches = nil
+	for _, match := range fuzzy.Find(m.input.Value(), m.paths) {
+		m.matches = append(m.matches, match.Str)
+	}
+
+	if m.input.Value() == "" {
+		m.matches = slices.Clone(m.paths)
+	}
+
+	m.cursor = 0
+	m.offset = 0
+}
+
+func (m *Model) open(path string) {
+	body, err := m.store.Body(path)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+
+	style := render.StyleLight
+	if m.renderer.HasDarkBackground() {
+		style = render.StyleDark
+	}
+
+	out, err := render.Markdown(body, m.width, style, glamour.WithColorProfile(m.renderer.ColorProfile()))
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+
+	m.current = path
+	m.viewport.SetContent(out)
+	m.viewport.GotoTop()
+	m.mode = previewing
+}
+
+func (m *Model) prompt(next mode, value string) tea.Cmd {
+	m.prev = m.mode
+	m.mode = next
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m *Model) doMove(to string) {
+	to = filepath.Clean(strings.TrimSpace(to))
+	err := m.store.Move(m.current, to)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+
+	m.message = fmt.Sprintf("moved %s to %s", m.current, to)
+	for i, path := range m.paths {
+		if path == m.current {
+			m.paths[i] = to
+		}
+	}
+
+	slices.Sort(m.paths)
+	m.buildTree()
+}
+
+func (m *Model) doDelete() {
+	err := m.store.Delete(m.current)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+
+	m.message = fmt.Sprintf("deleted %s", m.current)
+	m.paths = slices.DeleteFunc(m.paths, func(path string) bool { return path == m.current })
+	m.buildTree()
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.viewport.Width = msg.Width
+		m.viewport.Height = max(msg.Height-2, 1)
+		if m.mode == previewing {
+			m.open(m.current)
+		}
+
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		switch m.mode {
+		case browsing:
+			return m.updateBrowsing(msg)
+		case finding:
+			return m.updateFinding(msg)
+		case previewing:
+			return m.updatePreviewing(msg)
+		case moving, deleting:
+			return m.updatePrompt(msg)
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		m.moveCursor(-1)
+	case "down", "j":
+		m.moveCursor(1)
+	case "pgup":
+		m.moveCursor(-m.listHeight())
+	case "pgdown":
+		m.moveCursor(m.listHeight())
+	case "left", "h":
+		path, isDir := m.selected()
+		if isDir && m.expanded[path] {
+			m.expanded[path] = false
+			m.flatten()
+		} else if dir := filepath.Dir(path); dir != "." {
+			m.expanded[dir] = false
+			m.flatten()
+			m.cursor = slices.IndexFunc(m.visible, func(it *item) bool { return it.path == dir })
+			m.moveCursor(0)
+		}
+	case "right", "l", "enter":
+		path, isDir := m.selected()
+		if isDir {
+			if msg.String() == "enter" {
+				m.expanded[path] = !m.expanded[path]
+			} else {
+				m.expanded[path] = true
+			}
+			m.flatten()
+		} else if path != "" {
+			m.open(path)
+		}
+	case "/":
+		m.find()
+		cmd := m.prompt(finding, "")
+		return m, cmd
+	case "r":
+		m.refresh()
+	case "m":
+		path, isDir := m.selected()
+		if path != "" && !isDir {
+			m.current = path
+			cmd := m.prompt(moving, path)
+			return m, cmd
+		}
+	case "d":
+		path, isDir := m.selected()
+		if path != "" && !isDir {
+			m.current = path
+			cmd := m.prompt(deleting, "")
+			return m, cmd
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) updateFinding(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = browsing
+		m.input.Blur()
+		m.cursor = 0
+		m.offset = 0
+		return m, nil
+	case "up", "ctrl+p":
+		m.moveCursor(-1)
+		return m, nil
+	case "down", "ctrl+n":
+		m.moveCursor(1)
+		return m, nil
+	case "enter":
+		path, _ := m.selected()
+		if path != "" {
+			m.input.Blur()
+			m.open(path)
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.find()
+	return m, cmd
+}
+
+func (m Model) updatePreviewing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+	switch msg.String() {
+	case "q", "esc":
+		m.mode = browsing
+		return m, nil
+	case "m":
+		cmd := m.prompt(moving, m.current)
+		return m, cmd
+	case "d":
+		cmd := m.prompt(deleting, "")
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = m.prev
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		m.input.Blur()
+		if m.mode == moving {
+			m.doMove(m.input.Value())
+		} else if strings.EqualFold(strings.TrimSpace(m.input.Value()), "y") {
+			m.doDelete()
+		}
+
+		m.mode = browsing
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) listView() string {
+	sb := &strings.Builder{}
+	end := min(m.offset+m.listHeight(), m.listLength())
+	for i := m.offset; i < end; i++ {
+		var line string
+		if m.mode == finding {
+			line = m.matches[i]
+		} else {
+			it := m.visible[i]
+			if it.isDir {
+				marker := "+ "
+				if m.expanded[it.path] {
+					marker = "- "
+				}
+
+				line = m.styles.dir.Render(strings.Repeat("  ", it.depth) + marker + it.name + "/")
+			} else {
+				line = strings.Repeat("  ", it.depth) + "  " + it.name
+			}
+		}
+
+		if i == m.cursor {
+			line = m.styles.selected.Render(line)
+		}
+
+		fmt.Fprintln(sb, line)
+	}
+
+	for i := end - m.offset; i < m.listHeight(); i++ {
+		fmt.Fprintln(sb)
+	}
+
+	return sb.String()
+}
+
+func (m Model) footer() string {
+	switch m.mode {
+	case finding:
+		return m.input.View()
+	case moving:
+		return "move to " + m.input.View()
+	case deleting:
+		return fmt.Sprintf("delete %s? (y/N) %s", m.current, m.input.View())
+	}
+
+	if m.message != "" {
+		return m.styles.message.Render(m.message)
+	}
+
+	if m.mode == previewing {
+		return m.styles.help.Render("↑/↓ scroll • m move • d delete • esc back")
+	}
+
+	return m.styles.help.Render("↑/↓ navigate • enter open • / find • m move • d delete • r refresh • q quit")
+}
+
+func (m Model) View() string {
+	promptOverPreview := (m.mode == moving || m.mode == deleting) && m.prev == previewing
+	if m.mode == previewing || promptOverPreview {
+		return m.styles.title.Render(m.current) + "\n" + m.viewport.View() + "\n" + m.footer()
+	}
+
+	return m.styles.title.Render("brain") + "\n" + m.listView() + "\n" + m.footer()
+}
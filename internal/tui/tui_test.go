@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type fakeStore struct {
+	bodies  map[string]string
+	moved   [][2]string
+	deleted []string
+}
+
+func (f *fakeStore) Paths() []string {
+	var paths []string
+	for path := range f.bodies {
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+func (f *fakeStore) Body(path string) ([]byte, error) {
+	return []byte(f.bodies[path]), nil
+}
+
+func (f *fakeStore) Move(from, to string) error {
+	f.moved = append(f.moved, [2]string{from, to})
+	return nil
+}
+
+func (f *fakeStore) Delete(path string) error {
+	f.deleted = append(f.deleted, path)
+	return nil
+}
+
+var keys = map[string]tea.KeyType{
+	"enter":  tea.KeyEnter,
+	"esc":    tea.KeyEsc,
+	"up":     tea.KeyUp,
+	"down":   tea.KeyDown,
+	"left":   tea.KeyLeft,
+	"right":  tea.KeyRight,
+	"ctrl+u": tea.KeyCtrlU,
+}
+
+// press sends each key to m, typing any that are not named keys.
+func press(m Model, presses ...string) Model {
+	for _, press := range presses {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(press)}
+		if key, ok := keys[press]; ok {
+			msg = tea.KeyMsg{Type: key}
+		}
+
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+
+	return m
+}
+
+func newTestModel() (Model, *fakeStore) {
+	store := &fakeStore{bodies: map[string]string{
+		"a.md":       "# A",
+		"notes/b.md": "# B\n\nbody of b",
+		"notes/c.md": "# C",
+	}}
+
+	return New(store, lipgloss.NewRenderer(io.Discard), 80, 20), store
+}
+
+func TestBrowse(t *testing.T) {
+	m, _ := newTestModel()
+	if len(m.visible) != 2 {
+		t.Fatalf("expected a.md and notes/ got %d items", len(m.visible))
+	}
+
+	m = press(m, "down", "right")
+	if len(m.visible) != 4 {
+		t.Fatalf("expected notes/ to expand got %d items", len(m.visible))
+	}
+
+	m = press(m, "down", "enter")
+	if m.mode != previewing || m.current != "notes/b.md" || !strings.Contains(m.View(), "body of b") {
+		t.Errorf("expected notes/b.md preview got mode %d %q:\n%s", m.mode, m.current, m.View())
+	}
+
+	m = press(m, "esc", "left")
+	if m.mode != browsing || len(m.visible) != 2 {
+		t.Errorf("expected to return to the collapsed list got mode %d with %d items", m.mode, len(m.visible))
+	}
+
+	if path, isDir := m.selected(); path != "notes" || !isDir {
+		t.Errorf("expected notes/ selected got %q", path)
+	}
+}
+
+func TestFind(t *testing.T) {
+	m, _ := newTestModel()
+	m = press(m, "/", "c")
+	if !slices.Equal(m.matches, []string{"notes/c.md"}) {
+		t.Fatalf("expected notes/c.md to match got %v", m.matches)
+	}
+
+	m = press(m, "enter")
+	if m.mode != previewing || m.current != "notes/c.md" {
+		t.Errorf("expected notes/c.md preview got mode %d %q", m.mode, m.current)
+	}
+}
+
+func TestMoveAndDelete(t *testing.T) {
+	m, store := newTestModel()
+	m = press(m, "m", "ctrl+u", "z.md", "enter")
+	if len(store.moved) != 1 || store.moved[0] != [2]string{"a.md", "z.md"} {
+		t.Fatalf("expected a.md to move to z.md got %v", store.moved)
+	}
+
+	if !slices.Contains(m.paths, "z.md") || slices.Contains(m.paths, "a.md") {
+		t.Errorf("expected paths to show the move got %v", m.paths)
+	}
+
+	// notes/ now sorts first
+	m = press(m, "down", "d", "enter")
+	if len(store.deleted) != 0 {
+		t.Fatalf("expected delete without confirmation to be cancelled got %v", store.deleted)
+	}
+
+	m = press(m, "d", "y", "enter")
+	if !slices.Equal(store.deleted, []string{"z.md"}) || slices.Contains(m.paths, "z.md") {
+		t.Errorf("expected z.md to be deleted got %v with paths %v", store.deleted, m.paths)
+	}
+}